@@ -0,0 +1,217 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wert2all/ai-commit/project"
+)
+
+const (
+	defaultCacheTTL        = 24 * time.Hour
+	defaultCacheMaxEntries = 200
+	cacheFileSuffix        = ".json"
+)
+
+type (
+	// CacheConfig limits how long and how many responses are kept
+	CacheConfig struct {
+		TTL        string `json:"ttl"`
+		MaxEntries int    `json:"max_entries"`
+	}
+	// cachedProvider answers repeated requests for identical context from disk
+	cachedProvider struct {
+		provider     Provider
+		providerType ProviderType
+		endpoint     string
+		dir          string
+		ttl          time.Duration
+		maxEntries   int
+	}
+	cacheEntry struct {
+		Messages []string `json:"messages"`
+	}
+	cacheFile struct {
+		path     string
+		modified time.Time
+	}
+)
+
+// CacheDir returns the response cache location under the user cache directory
+func CacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error resolving cache directory: %v", err)
+	}
+	return filepath.Join(cacheDir, "ai-commit", "responses"), nil
+}
+
+// ClearCache removes every cached response and returns how many there were
+func ClearCache() (int, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return 0, err
+	}
+	files, err := cacheFiles(dir)
+	if err != nil {
+		return 0, err
+	}
+	for _, file := range files {
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("error removing cached response: %v", err)
+		}
+	}
+	return len(files), nil
+}
+
+// newCachedProvider wraps language model providers with the cache unless --no-cache is given
+func newCachedProvider(config Config) (Provider, error) {
+	provider, err := newProvider(config)
+	if err != nil || config.Options.NoCache || config.Type == ProviderHeuristic {
+		return provider, err
+	}
+
+	dir, err := CacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := defaultCacheTTL
+	if config.File.Cache.TTL != "" {
+		ttl, err = time.ParseDuration(config.File.Cache.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache ttl: %v", err)
+		}
+	}
+	maxEntries := config.File.Cache.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+
+	return &cachedProvider{
+		provider:     provider,
+		providerType: config.Type,
+		endpoint:     config.Endpoint,
+		dir:          dir,
+		ttl:          ttl,
+		maxEntries:   maxEntries,
+	}, nil
+}
+
+func (c *cachedProvider) GetProviderInfo() ProviderInfo {
+	return c.provider.GetProviderInfo()
+}
+
+// GenerateCommitMessage returns a cached response without usage when one is fresh enough.
+// The cache is best effort, failing to read or write it never fails the generation.
+func (c *cachedProvider) GenerateCommitMessage(projectContext project.ProjectContext, candidates int) (*Response, error) {
+	path := filepath.Join(c.dir, c.key(projectContext, candidates)+cacheFileSuffix)
+
+	if entry, ok := c.read(path); ok {
+		return &Response{Messages: entry.Messages, Cached: true}, nil
+	}
+
+	response, err := c.provider.GenerateCommitMessage(projectContext, candidates)
+	if err != nil {
+		return nil, err
+	}
+	c.write(path, cacheEntry{Messages: response.Messages})
+	return response, nil
+}
+
+// key hashes everything the response depends on
+func (c *cachedProvider) key(projectContext project.ProjectContext, candidates int) string {
+	hash := sha256.New()
+	for _, part := range []string{
+		string(c.providerType),
+		c.endpoint,
+		c.provider.GetProviderInfo().Model,
+		projectContext.SystemPrompt,
+		projectContext.Context,
+		strconv.FormatBool(projectContext.Multiline),
+		strconv.Itoa(projectContext.MaxTokens),
+		strconv.Itoa(candidates),
+	} {
+		// length prefixes keep ("ab", "c") and ("a", "bc") apart
+		fmt.Fprintf(hash, "%d:%s", len(part), part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *cachedProvider) read(path string) (cacheEntry, bool) {
+	var entry cacheEntry
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > c.ttl {
+		return entry, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(content, &entry); err != nil || len(entry.Messages) == 0 {
+		return entry, false
+	}
+	return entry, true
+}
+
+func (c *cachedProvider) write(path string, entry cacheEntry) {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return
+	}
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return
+	}
+	c.prune()
+}
+
+// prune drops expired responses and the oldest ones above the size limit
+func (c *cachedProvider) prune() {
+	files, err := cacheFiles(c.dir)
+	if err != nil {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modified.After(files[j].modified)
+	})
+	for i, file := range files {
+		if i >= c.maxEntries || time.Since(file.modified) > c.ttl {
+			// nolint
+			os.Remove(file.path)
+		}
+	}
+}
+
+func cacheFiles(dir string) ([]cacheFile, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache directory: %v", err)
+	}
+
+	files := make([]cacheFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), cacheFileSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{path: filepath.Join(dir, entry.Name()), modified: info.ModTime()})
+	}
+	return files, nil
+}
@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/wert2all/ai-commit/project"
 )
 
+const anthropicVersion = "2023-06-01"
+
 type ClaudeProvider struct {
 	apiKey string
 	model  string
@@ -21,60 +24,95 @@ func (p *ClaudeProvider) GetProviderInfo() ProviderInfo {
 }
 
 type claudeRequest struct {
-	Model       string   `json:"model"`
-	Prompt      string   `json:"prompt"`
-	MaxTokens   int      `json:"max_tokens_to_sample"`
-	Temperature float64  `json:"temperature"`
-	Stop        []string `json:"stop_sequences"`
+	Model       string          `json:"model"`
+	System      string          `json:"system"`
+	Messages    []claudeMessage `json:"messages"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature float64         `json:"temperature"`
+}
+
+type claudeMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
 }
 
 type claudeResponse struct {
-	Completion string `json:"completion"`
-	Stop       string `json:"stop"`
-	Model      string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Model string `json:"model"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (p *ClaudeProvider) GenerateCommitMessage(projectContext project.ProjectContext, candidates int) (*Response, error) {
+	return repeatGenerate(candidates, func() (string, Usage, error) { return p.generate(projectContext) })
 }
 
-func (p *ClaudeProvider) GenerateCommitMessage(projectContext project.ProjectContext) (string, error) {
+func (p *ClaudeProvider) generate(projectContext project.ProjectContext) (string, Usage, error) {
 	req := claudeRequest{
-		Model:       p.model,
-		Prompt:      fmt.Sprintf("\n\nHuman: Project Context:\n\n%s\n\nAssistant: %s", projectContext.Context, projectContext.SystemPrompt),
-		MaxTokens:   50,
+		Model:  p.model,
+		System: projectContext.SystemPrompt,
+		Messages: []claudeMessage{
+			{
+				Role:    "user",
+				Content: fmt.Sprintf("Project Context:\n\n%s\n\n", projectContext.Context),
+			},
+		},
+		MaxTokens:   maxTokens(projectContext),
 		Temperature: 0.7,
-		Stop:        []string{"\n", "Human:"},
 	}
 
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %v", err)
+		return "", Usage{}, fmt.Errorf("error marshaling request: %v", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", "https://api.anthropic.com/v1/complete", bytes.NewBuffer(reqBody))
+	httpReq, err := http.NewRequest("POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %v", err)
+		return "", Usage{}, fmt.Errorf("error creating request: %v", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("X-API-Key", p.apiKey)
+	httpReq.Header.Set("Anthropic-Version", anthropicVersion)
 
 	client := &http.Client{}
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("error making request: %v", err)
+		return "", Usage{}, fmt.Errorf("error making request: %v", err)
 	}
 	// nolint
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("error from Claude API (status %d): %s", resp.StatusCode, string(body))
+		return "", Usage{}, fmt.Errorf("error from Claude API (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var result claudeResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("error decoding response: %v", err)
+		return "", Usage{}, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	var text strings.Builder
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	completion := strings.TrimSpace(text.String())
+	if !projectContext.Multiline {
+		// whitespace-only stop sequences are not accepted, so keep the subject line here
+		completion, _, _ = strings.Cut(completion, "\n")
 	}
 
-	return result.Completion, nil
+	usage := Usage{PromptTokens: result.Usage.InputTokens, CompletionTokens: result.Usage.OutputTokens}
+	return completion, usage, nil
 }
 
 func NewClaudeProvider(apiKey string, model string) *ClaudeProvider {
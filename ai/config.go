@@ -1,37 +1,175 @@
 package ai
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const maxCandidates = 10
+
+const (
+	OutputText = "text"
+	OutputJSON = "json"
 )
 
 type Options struct {
 	WithCommit              bool
 	WithChangedFilesContent bool
+	WithProjectConfig       bool
+	WithBody                bool
+	Plain                   bool
+	Fix                     bool
+	Yes                     bool
+	PrintOnly               bool
+	Output                  string
+	Since                   string
+	Until                   string
+	GroupBy                 string
+	Template                string
+	From                    string
+	To                      string
+	Format                  string
+	Release                 string
+	Notes                   bool
+	Force                   bool
+	Score                   bool
+	MinScore                int
+	Create                  bool
+	Ticket                  string
+	File                    string
+	NoCache                 bool
 	ShowVersion             bool
 }
 
 type Config struct {
-	Directory string
-	Type      ProviderType
-	Endpoint  string
-	APIKey    string
-	Model     string
-	Options   Options
+	Directory  string
+	RepoRoot   string
+	Type       ProviderType
+	Endpoint   string
+	APIKey     string
+	Model      string
+	Candidates int
+	// Fallback providers are asked in order when the selected one fails
+	Fallback []ProviderType
+	Options  Options
+	File     FileConfig
+	// Args holds the positional arguments of a subcommand
+	Args []string
+	// Flags holds the flags as given on the command line, to pass them on to hooks
+	Flags []string
+}
+
+// ConfigError is returned by ReadConfig for invalid flags and configuration files
+type ConfigError struct {
+	// Output is the --output format as given, so the error can be reported in it
+	Output string
+	Err    error
+}
+
+func (e *ConfigError) Error() string { return e.Err.Error() }
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// ReadConfig parses the command line and the configuration files. Invalid flags and
+// configuration are returned as *ConfigError, -h and --help print the usage and return flag.ErrHelp.
+func ReadConfig(args []string) (*Config, error) {
+	config, err := readConfig(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return nil, &ConfigError{Output: outputFlag(args), Err: err}
+	}
+	return config, err
+}
+
+// outputFlag finds the --output value without parsing the other flags, which may be invalid
+func outputFlag(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, found := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != "output" || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if found {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return OutputText
 }
 
-func ReadConfig() (*Config, error) {
-	providerName := flag.String("provider", "openai", "AI provider to use (openai, claude, mistral, gemini, openrouter, local)")
-	model := flag.String("model", "", "Model to use (e.g., gpt-3.5-turbo, claude-2, mistral-medium, gemini-pro)")
-	projectDir := flag.String("dir", ".", "Project directory path")
-	endpoint := flag.String("endpoint", "", "Local provider endpoint1")
-	withoutCommit := flag.Bool("without-commit", false, "commit a source after generate")
-	withFilesContent := flag.Bool("with-files-content", false, "include content of changed files to context")
-	showVersion := flag.Bool("version", false, "show version")
+func readConfig(args []string) (*Config, error) {
+	flags := flag.NewFlagSet("ai-commit", flag.ContinueOnError)
+	// errors are reported by the caller, in JSON with --output json
+	flags.SetOutput(io.Discard)
+	providerName := flags.String("provider", "openai", "AI provider to use (openai, claude, mistral, gemini, openrouter, local, heuristic)")
+	model := flags.String("model", "", "Model to use (e.g., gpt-3.5-turbo, claude-2, mistral-medium, gemini-pro)")
+	projectDir := flags.String("dir", ".", "Project directory path")
+	endpoint := flags.String("endpoint", "", "Local provider endpoint1")
+	withoutCommit := flags.Bool("without-commit", false, "commit a source after generate")
+	withFilesContent := flags.Bool("with-files-content", false, "include content of changed files to context")
+	withProjectConfig := flags.Bool("with-project-config", false, "include changed build, CI and container manifests in the context")
+	candidates := flags.Int("candidates", 1, "number of alternative messages to generate")
+	withBody := flags.Bool("body", false, "generate a message body and footers in addition to the subject")
+	plain := flags.Bool("plain", false, "use the plain confirmation prompt instead of the interactive UI")
+	fix := flags.Bool("fix", false, "let the commit-msg hook rewrite non-compliant messages instead of rejecting them")
+	var yes bool
+	flags.BoolVar(&yes, "yes", false, "commit with the generated message without asking")
+	flags.BoolVar(&yes, "no-confirm", false, "alias for --yes")
+	printOnly := flags.Bool("print-only", false, "print only the raw commit message and do not commit")
+	output := flags.String("output", OutputText, "output format (text, json)")
+	since := flags.String("since", "", "stats: only runs on or after this date (YYYY-MM-DD)")
+	until := flags.String("until", "", "stats: only runs on or before this date (YYYY-MM-DD)")
+	groupBy := flags.String("group-by", "model", "stats: group runs by provider, model or repo")
+	template := flags.String("template", "", "pr: pull request template to fill in (e.g. .github/pull_request_template.md)")
+	from := flags.String("from", "", "changelog: first revision, exclusive (default: latest tag)")
+	to := flags.String("to", "HEAD", "changelog: last revision")
+	format := flags.String("format", "markdown", "changelog: format (markdown, keepachangelog)")
+	release := flags.String("release", "", "changelog: version heading of the section (default: --to when it is not HEAD, else Unreleased)")
+	notes := flags.Bool("notes", false, "changelog: let the provider write release notes for each section")
+	file := flags.String("file", "", "changelog: insert the section into this file (e.g. CHANGELOG.md)")
+	force := flags.Bool("force", false, "reword: rewrite commits that are already on a remote or protected branch")
+	score := flags.Bool("score", false, "lint: let the provider rate how well each subject describes its changes")
+	minScore := flags.Int("min-score", 0, "lint: fail when a subject scores below this, from 1 to 5 (implies --score)")
+	create := flags.Bool("create", false, "branch: create the suggested branch and switch to it")
+	ticket := flags.String("ticket", "", "branch: ticket for the <ticket> placeholder of the pattern (e.g. ABC-123)")
+	fallback := flags.String("fallback", "", "comma separated providers to try when the selected one fails (e.g. local,heuristic)")
+	noCache := flags.Bool("no-cache", false, "always ask the provider instead of reusing a cached response")
+	showVersion := flags.Bool("version", false, "show version")
 
-	flag.Parse()
+	// Allow flags after subcommand arguments, e.g. "hook install --provider claude"
+	positional := make([]string, 0)
+	given := make([]string, 0)
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				flags.SetOutput(os.Stderr)
+				flags.Usage()
+			}
+			return nil, err
+		}
+		parsed := args[:len(args)-flags.NArg()]
+		// everything after "--" is positional, even when it looks like a flag
+		if len(parsed) > 0 && parsed[len(parsed)-1] == "--" {
+			given = append(given, parsed[:len(parsed)-1]...)
+			positional = append(positional, flags.Args()...)
+			break
+		}
+		given = append(given, parsed...)
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
 
 	// Convert relative path to absolute
 	absProjectDir, err := filepath.Abs(*projectDir)
@@ -39,26 +177,82 @@ func ReadConfig() (*Config, error) {
 		return nil, fmt.Errorf("error resolving project directory path: %v", err)
 	}
 
-	// Get API key based on provider
-	apiKey, err := getAPIKey(*providerName)
+	if *candidates < 1 || *candidates > maxCandidates {
+		return nil, fmt.Errorf("candidates must be between 1 and %d", maxCandidates)
+	}
+
+	if *output != OutputText && *output != OutputJSON {
+		return nil, fmt.Errorf("unknown output format: %s", *output)
+	}
+
+	fallbackTypes := make([]ProviderType, 0)
+	for name := range strings.SplitSeq(*fallback, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !slices.Contains(ProviderTypes, ProviderType(name)) {
+			return nil, fmt.Errorf("unknown fallback provider: %s", name)
+		}
+		fallbackTypes = append(fallbackTypes, ProviderType(name))
+	}
+
+	absRepoRoot := repoRoot(absProjectDir)
+	fileConfig, err := readFileConfig(absRepoRoot)
 	if err != nil {
 		return nil, err
 	}
+
 	config := Config{
-		Type:      ProviderType(*providerName),
-		APIKey:    apiKey,
-		Model:     *model,
-		Endpoint:  *endpoint,
-		Directory: absProjectDir,
+		Type:       ProviderType(*providerName),
+		Model:      *model,
+		Endpoint:   *endpoint,
+		Directory:  absProjectDir,
+		RepoRoot:   absRepoRoot,
+		Candidates: *candidates,
+		Fallback:   fallbackTypes,
 		Options: Options{
 			WithCommit:              !*withoutCommit,
 			WithChangedFilesContent: *withFilesContent,
+			WithProjectConfig:       *withProjectConfig,
+			WithBody:                *withBody,
+			Plain:                   *plain,
+			Fix:                     *fix,
+			Yes:                     yes,
+			PrintOnly:               *printOnly,
+			Output:                  *output,
+			Since:                   *since,
+			Until:                   *until,
+			GroupBy:                 *groupBy,
+			Template:                *template,
+			From:                    *from,
+			To:                      *to,
+			Format:                  *format,
+			Release:                 *release,
+			Notes:                   *notes,
+			Force:                   *force,
+			Score:                   *score || *minScore > 0,
+			MinScore:                *minScore,
+			Create:                  *create,
+			Ticket:                  *ticket,
+			File:                    *file,
+			NoCache:                 *noCache,
 			ShowVersion:             *showVersion,
 		},
+		File:  fileConfig,
+		Args:  positional,
+		Flags: given,
 	}
 	return &config, nil
 }
 
+// WithProvider returns a copy of the config switched to another provider and model
+func (c Config) WithProvider(providerType ProviderType, model string) Config {
+	c.Type = providerType
+	c.Model = model
+	c.APIKey = ""
+	return c
+}
+
 func getAPIKey(providerName string) (string, error) {
 	switch providerName {
 	case "openai":
@@ -95,8 +289,8 @@ func getAPIKey(providerName string) (string, error) {
 			return "", fmt.Errorf("OPENROUTER_API_KEY environment variable is not set")
 		}
 		return apiKey, nil
-	case "local":
-		// No API key needed for local provider
+	case "local", "heuristic":
+		// No API key needed for local providers
 		return "", nil
 
 	default:
@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReadConfigStopsAtTerminator(t *testing.T) {
+	config, err := ReadConfig([]string{"--dir", t.TempDir(), "lint", "--yes", "--", "--body", "-x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"lint", "--body", "-x"}; !slices.Equal(config.Args, want) {
+		t.Errorf("Args = %q, want %q", config.Args, want)
+	}
+	if config.Options.WithBody {
+		t.Error("--body after -- was parsed as a flag")
+	}
+	if !config.Options.Yes {
+		t.Error("--yes before -- was not parsed")
+	}
+	if want := []string{"--dir", config.Flags[1], "--yes"}; !slices.Equal(config.Flags, want) {
+		t.Errorf("Flags = %q, want %q", config.Flags, want)
+	}
+}
+
+func TestReadConfigErrorsKeepTheOutputFormat(t *testing.T) {
+	for _, args := range [][]string{
+		{"--output", "json", "--candidates", "20"},
+		{"--output=json", "--bogus"},
+		{"--bogus", "-output", "json"},
+		{"--output", "json", "--fallback", "nope"},
+	} {
+		_, err := ReadConfig(append([]string{"--dir", t.TempDir()}, args...))
+		var configErr *ConfigError
+		if !errors.As(err, &configErr) {
+			t.Errorf("ReadConfig(%q) = %v, want a *ConfigError", args, err)
+			continue
+		}
+		if configErr.Output != OutputJSON {
+			t.Errorf("ReadConfig(%q) reports output %q, want json", args, configErr.Output)
+		}
+	}
+
+	_, err := ReadConfig([]string{"--candidates", "20", "--", "--output", "json"})
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Output != OutputText {
+		t.Errorf("--output after -- was read as a flag: %v", err)
+	}
+}
@@ -9,11 +9,14 @@ import (
 type (
 	ProviderType string
 	ProviderInfo struct {
-		Name  string
-		Model string
+		// Type is filled in by NewProvider, it tells which provider of a fallback chain answered
+		Type  ProviderType `json:"type"`
+		Name  string       `json:"name"`
+		Model string       `json:"model"`
 	}
 	Provider interface {
-		GenerateCommitMessage(projectContext project.ProjectContext) (string, error)
+		// GenerateCommitMessage asks for up to candidates alternative messages
+		GenerateCommitMessage(projectContext project.ProjectContext, candidates int) (*Response, error)
 		GetProviderInfo() ProviderInfo
 	}
 )
@@ -25,9 +28,86 @@ const (
 	ProviderGemini     ProviderType = "gemini"
 	ProviderOpenRouter ProviderType = "openrouter"
 	ProviderLocal      ProviderType = "local"
+	ProviderHeuristic  ProviderType = "heuristic"
 )
 
+// ProviderTypes lists the supported providers in the order they are offered to the user
+var ProviderTypes = []ProviderType{
+	ProviderOpenAI,
+	ProviderClaude,
+	ProviderMistral,
+	ProviderGemini,
+	ProviderOpenRouter,
+	ProviderLocal,
+	ProviderHeuristic,
+}
+
+const (
+	subjectMaxTokens = 50
+	bodyMaxTokens    = 1024
+)
+
+// maxTokens returns the completion budget for the expected message shape
+func maxTokens(projectContext project.ProjectContext) int {
+	if projectContext.MaxTokens > 0 {
+		return projectContext.MaxTokens
+	}
+	if projectContext.Multiline {
+		return bodyMaxTokens
+	}
+	return subjectMaxTokens
+}
+
+// IsRemote reports whether the provider sends the context off the machine
+func (t ProviderType) IsRemote() bool {
+	return t != ProviderLocal && t != ProviderHeuristic
+}
+
+// NewProvider creates the configured provider followed by its --fallback providers.
+// Each one answers from the response cache unless --no-cache is given.
+// Any of them not allowed by the policy is an error, rather than skipped in the chain.
 func NewProvider(config Config) (Provider, error) {
+	providerTypes := append([]ProviderType{config.Type}, config.Fallback...)
+	for _, providerType := range providerTypes {
+		if err := config.File.Policy.checkProvider(providerType); err != nil {
+			return nil, err
+		}
+	}
+
+	chain := &chainProvider{
+		entries:      make([]chainEntry, 0, len(providerTypes)),
+		policy:       config.File.Policy,
+		blockSecrets: config.File.Redaction.BlockRemote,
+	}
+	for i, providerType := range providerTypes {
+		entryConfig := config
+		if i > 0 {
+			// fallbacks use their default model
+			entryConfig = config.WithProvider(providerType, "")
+		}
+		provider, err := newCachedProvider(entryConfig)
+		if err != nil && len(config.Fallback) == 0 {
+			return nil, err
+		}
+		chain.entries = append(chain.entries, chainEntry{providerType: providerType, provider: provider, err: err})
+	}
+	return chain, nil
+}
+
+func newProvider(config Config) (Provider, error) {
+	if err := config.File.Policy.checkProvider(config.Type); err != nil {
+		return nil, err
+	}
+
+	// Get API key based on provider
+	if config.APIKey == "" {
+		apiKey, err := getAPIKey(string(config.Type))
+		if err != nil {
+			return nil, err
+		}
+		config.APIKey = apiKey
+	}
+
 	switch config.Type {
 	case ProviderMistral:
 		return NewMistralProvider(config.APIKey, config.Model), nil
@@ -44,6 +124,8 @@ func NewProvider(config Config) (Provider, error) {
 			return nil, fmt.Errorf("empty model")
 		}
 		return NewLocalProvider(config.Endpoint, config.Model), nil
+	case ProviderHeuristic:
+		return NewHeuristicProvider(), nil
 	default:
 		return nil, fmt.Errorf("unknown provider type: %s", config.Type)
 	}
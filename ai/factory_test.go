@@ -0,0 +1,22 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewProviderRefusesFallbacksNotAllowedByPolicy(t *testing.T) {
+	config := Config{
+		Type:     ProviderHeuristic,
+		Fallback: []ProviderType{ProviderLocal, ProviderOpenAI},
+		File:     FileConfig{Policy: Policy{AllowedProviders: []ProviderType{ProviderHeuristic, ProviderLocal}}},
+	}
+	if _, err := NewProvider(config); err == nil || !strings.Contains(err.Error(), "provider openai is not allowed by policy") {
+		t.Errorf("NewProvider() error = %v, want the policy error", err)
+	}
+
+	config.Type, config.Fallback = ProviderOpenAI, []ProviderType{ProviderHeuristic}
+	if _, err := NewProvider(config); err == nil || !strings.Contains(err.Error(), "provider openai is not allowed by policy") {
+		t.Errorf("NewProvider() error = %v, want the policy error", err)
+	}
+}
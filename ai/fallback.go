@@ -0,0 +1,73 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wert2all/ai-commit/project"
+)
+
+type (
+	// chainProvider asks its providers in order until one answers,
+	// skipping those the policy does not allow to see the context
+	chainProvider struct {
+		entries      []chainEntry
+		policy       Policy
+		blockSecrets bool
+		answered     int
+	}
+	chainEntry struct {
+		providerType ProviderType
+		provider     Provider
+		// err is set when the provider could not be created, e.g. for a missing API key
+		err error
+	}
+)
+
+// GetProviderInfo implements ai.Provider. It describes the provider that answered last.
+func (c *chainProvider) GetProviderInfo() ProviderInfo {
+	entry := c.entries[c.answered]
+	info := ProviderInfo{Name: string(entry.providerType)}
+	if entry.provider != nil {
+		info = entry.provider.GetProviderInfo()
+	}
+	info.Type = entry.providerType
+	return info
+}
+
+// GenerateCommitMessage implements ai.Provider.
+func (c *chainProvider) GenerateCommitMessage(projectContext project.ProjectContext, candidates int) (*Response, error) {
+	failures := make([]string, 0, len(c.entries))
+	for i, entry := range c.entries {
+		err := entry.err
+		if err == nil {
+			err = c.check(entry.providerType, projectContext)
+		}
+		if err == nil {
+			var response *Response
+			response, err = entry.provider.GenerateCommitMessage(projectContext, candidates)
+			if err == nil {
+				c.answered = i
+				response.Failures = failures
+				return response, nil
+			}
+		}
+		if len(c.entries) == 1 {
+			return nil, err
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", entry.providerType, err))
+	}
+	return nil, fmt.Errorf("all providers failed: %s", strings.Join(failures, "; "))
+}
+
+// check refuses to send local-only changes or secrets to remote providers
+func (c *chainProvider) check(providerType ProviderType, projectContext project.ProjectContext) error {
+	if err := c.policy.checkChanges(providerType, projectContext); err != nil {
+		return err
+	}
+	if c.blockSecrets && providerType.IsRemote() && len(projectContext.Redactions) > 0 {
+		return fmt.Errorf("staged changes contain secrets (%s), refusing to send them to %s",
+			project.DescribeRedactions(projectContext.Redactions), providerType)
+	}
+	return nil
+}
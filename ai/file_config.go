@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/wert2all/ai-commit/branch"
+	"github.com/wert2all/ai-commit/message"
+	"github.com/wert2all/ai-commit/project"
+)
+
+// repoConfigFile is looked up in the repository root and overrides the user config
+const repoConfigFile = ".ai-commit.json"
+
+// FileConfig holds settings that are too structured for command line flags
+type FileConfig struct {
+	Convention message.Convention `json:"convention"`
+	// Prices override the built-in price table, keyed by model name or prefix
+	Prices map[string]Price `json:"prices"`
+	Cache  CacheConfig      `json:"cache"`
+	// Redaction replaces secrets in the context before it is sent
+	Redaction project.RedactionConfig `json:"redaction"`
+	Policy    Policy                  `json:"policy"`
+	// Scopes maps paths to commit scopes, workspace units are detected without it
+	Scopes project.ScopeConfig `json:"scopes"`
+	// ProtectedBranches are never reworded without --force, like remote branches
+	ProtectedBranches []string `json:"protected_branches"`
+	// Branch sets the pattern of suggested branch names
+	Branch branch.Config `json:"branch"`
+}
+
+// readFileConfig layers the repository config over the user config over the defaults
+func readFileConfig(absRepoRoot string) (FileConfig, error) {
+	fileConfig := FileConfig{
+		Convention: message.DefaultConvention(),
+		Redaction:  project.DefaultRedactionConfig(),
+		// develop is the integration branch of git-flow
+		ProtectedBranches: []string{"main", "master", "develop"},
+		Branch:            branch.Config{Pattern: branch.DefaultPattern},
+	}
+
+	paths := make([]string, 0, 2)
+	if userConfigDir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(userConfigDir, "ai-commit", "config.json"))
+	}
+	paths = append(paths, filepath.Join(absRepoRoot, repoConfigFile))
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fileConfig, fmt.Errorf("error reading config %s: %v", path, err)
+		}
+		if err := json.Unmarshal(content, &fileConfig); err != nil {
+			return fileConfig, fmt.Errorf("error parsing config %s: %v", path, err)
+		}
+	}
+	return fileConfig, nil
+}
+
+func repoRoot(absProjectDir string) string {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = absProjectDir
+	output, err := cmd.Output()
+	if err != nil {
+		return absProjectDir
+	}
+	return strings.TrimSpace(string(output))
+}
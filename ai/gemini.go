@@ -36,6 +36,7 @@ type part struct {
 type generationConfig struct {
 	Temperature     float64 `json:"temperature"`
 	MaxOutputTokens int     `json:"maxOutputTokens"`
+	CandidateCount  int     `json:"candidateCount,omitempty"`
 }
 
 type geminiResponse struct {
@@ -46,6 +47,10 @@ type geminiResponse struct {
 			} `json:"parts"`
 		} `json:"content"`
 	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
 }
 
 func NewGeminiProvider(apiKey string, model string) *GeminiProvider {
@@ -58,7 +63,7 @@ func NewGeminiProvider(apiKey string, model string) *GeminiProvider {
 	}
 }
 
-func (p *GeminiProvider) GenerateCommitMessage(projectContext project.ProjectContext) (string, error) {
+func (p *GeminiProvider) GenerateCommitMessage(projectContext project.ProjectContext, candidates int) (*Response, error) {
 	req := geminiRequest{
 		Contents: []content{
 			{
@@ -70,19 +75,20 @@ func (p *GeminiProvider) GenerateCommitMessage(projectContext project.ProjectCon
 		},
 		GenerationConfig: generationConfig{
 			Temperature:     0.7,
-			MaxOutputTokens: 50,
+			MaxOutputTokens: maxTokens(projectContext),
+			CandidateCount:  max(candidates, 1),
 		},
 	}
 
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %v", err)
+		return nil, fmt.Errorf("error marshaling request: %v", err)
 	}
 
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1/models/%s:generateContent?key=%s", p.model, p.apiKey)
 	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %v", err)
+		return nil, fmt.Errorf("error creating request: %v", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -90,24 +96,34 @@ func (p *GeminiProvider) GenerateCommitMessage(projectContext project.ProjectCon
 	client := &http.Client{}
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("error making request: %v", err)
+		return nil, fmt.Errorf("error making request: %v", err)
 	}
 	// nolint
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("error from Gemini API (status %d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("error from Gemini API (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var result geminiResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("error decoding response: %v", err)
+		return nil, fmt.Errorf("error decoding response: %v", err)
 	}
 
-	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no response from Gemini API")
+	messages := make([]string, 0, len(result.Candidates))
+	for _, candidate := range result.Candidates {
+		if len(candidate.Content.Parts) > 0 {
+			messages = append(messages, candidate.Content.Parts[0].Text)
+		}
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no response from Gemini API")
 	}
 
-	return result.Candidates[0].Content.Parts[0].Text, nil
+	usage := Usage{
+		PromptTokens:     result.UsageMetadata.PromptTokenCount,
+		CompletionTokens: result.UsageMetadata.CandidatesTokenCount,
+	}
+	return newResponse(messages, usage)
 }
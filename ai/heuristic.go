@@ -0,0 +1,309 @@
+package ai
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/message"
+	"github.com/wert2all/ai-commit/project"
+)
+
+// HeuristicProvider derives a message from the staged file list without a language model
+type HeuristicProvider struct{}
+
+// fileKind is the commit type a file suggests on its own
+type fileKind string
+
+const (
+	kindCode  fileKind = "code"
+	kindTest  fileKind = "test"
+	kindDocs  fileKind = "docs"
+	kindCI    fileKind = "ci"
+	kindBuild fileKind = "build"
+)
+
+var (
+	buildFiles = []string{
+		"go.mod", "go.sum", "go.work", "package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml",
+		"Cargo.toml", "Cargo.lock", "pom.xml", "build.gradle", "build.gradle.kts", "settings.gradle",
+		"composer.json", "composer.lock", "Gemfile", "Gemfile.lock", "requirements.txt", "pyproject.toml",
+		"Makefile", "Dockerfile", "docker-compose.yml", "docker-compose.yaml",
+	}
+	// dependencyFiles only change when dependencies do
+	dependencyFiles = []string{
+		"go.sum", "package-lock.json", "yarn.lock", "pnpm-lock.yaml", "Cargo.lock", "composer.lock", "Gemfile.lock",
+	}
+	docsExtensions = []string{".md", ".rst", ".adoc", ".txt"}
+	// genericDirs say nothing about the part of the code base and make poor scopes
+	genericDirs = []string{"src", "lib", "pkg", "internal", "cmd", "app", "main", "java", "kotlin", "test", "tests"}
+)
+
+const heuristicMaxFuncs = 2
+
+func NewHeuristicProvider() *HeuristicProvider {
+	return &HeuristicProvider{}
+}
+
+// GetProviderInfo implements ai.Provider.
+func (p *HeuristicProvider) GetProviderInfo() ProviderInfo {
+	return ProviderInfo{Name: "Heuristic", Model: "built-in rules"}
+}
+
+// GenerateCommitMessage implements ai.Provider. It always returns a single candidate.
+func (p *HeuristicProvider) GenerateCommitMessage(projectContext project.ProjectContext, candidates int) (*Response, error) {
+	if projectContext.Changes == nil || len(projectContext.Changes.Files()) == 0 {
+		return nil, changes.ErrNoChanges
+	}
+	files := projectContext.Changes.Files()
+
+	kind, relevant := dominantKind(files)
+	commitType := commitTypeFor(kind, relevant)
+	breaking := false
+	for _, change := range projectContext.SemanticChanges {
+		if change.Breaking {
+			breaking = true
+			break
+		}
+	}
+	scope := ""
+	switch {
+	case len(projectContext.Scopes) > 0:
+		scope = projectContext.Scopes[0]
+	case kind == kindCode || kind == kindTest:
+		scope = commonScope(relevant)
+	}
+
+	msg := message.Message{Subject: formatHeader(commitType, scope, breaking, subjectFor(kind, relevant))}
+	if projectContext.Multiline {
+		msg.Body = fileListBody(files)
+	}
+	return newResponse([]string{msg.String()}, Usage{})
+}
+
+// dominantKind returns the kind of the change and the files deciding it,
+// code outweighs tests which outweigh everything else
+func dominantKind(files []changes.FileDiff) (fileKind, []changes.FileDiff) {
+	byKind := make(map[fileKind][]changes.FileDiff)
+	for _, file := range files {
+		kind := classify(file.Path)
+		byKind[kind] = append(byKind[kind], file)
+	}
+	for _, kind := range []fileKind{kindCode, kindTest, kindBuild, kindCI, kindDocs} {
+		if len(byKind[kind]) > 0 {
+			return kind, byKind[kind]
+		}
+	}
+	return kindCode, files
+}
+
+func classify(filePath string) fileKind {
+	name := path.Base(filePath)
+	lower := strings.ToLower(filePath)
+	switch {
+	case strings.HasPrefix(lower, ".github/workflows/") || strings.HasPrefix(lower, ".circleci/") ||
+		name == ".gitlab-ci.yml" || name == "Jenkinsfile" || name == ".travis.yml":
+		return kindCI
+	case strings.Contains(name, "_test.") || strings.Contains(name, ".test.") || strings.Contains(name, ".spec.") ||
+		strings.HasPrefix(name, "test_") || project.MatchAny([]string{"test/", "tests/", "__tests__/", "spec/"}, filePath):
+		return kindTest
+	case slices.Contains(buildFiles, name):
+		return kindBuild
+	case strings.HasPrefix(strings.ToUpper(name), "README") || strings.HasPrefix(strings.ToUpper(name), "CHANGELOG") ||
+		slices.Contains(docsExtensions, path.Ext(lower)) || project.MatchPath("docs/", filePath):
+		return kindDocs
+	default:
+		return kindCode
+	}
+}
+
+func commitTypeFor(kind fileKind, files []changes.FileDiff) string {
+	if kind != kindCode {
+		return string(kind)
+	}
+	for _, file := range files {
+		if file.Status == changes.FileAdded || len(newFuncs(file)) > 0 {
+			return "feat"
+		}
+	}
+	return "refactor"
+}
+
+// commonScope names the deepest directory shared by all files, unless it is too generic
+func commonScope(files []changes.FileDiff) string {
+	if len(files) == 0 {
+		return ""
+	}
+	common := strings.Split(path.Dir(files[0].Path), "/")
+	for _, file := range files[1:] {
+		dirs := strings.Split(path.Dir(file.Path), "/")
+		length := 0
+		for length < len(common) && length < len(dirs) && common[length] == dirs[length] {
+			length++
+		}
+		common = common[:length]
+	}
+	for i := len(common) - 1; i >= 0; i-- {
+		dir := strings.ToLower(strings.TrimPrefix(common[i], "."))
+		if dir != "" && !slices.Contains(genericDirs, dir) {
+			return dir
+		}
+	}
+	return ""
+}
+
+func subjectFor(kind fileKind, files []changes.FileDiff) string {
+	switch kind {
+	case kindBuild:
+		for _, file := range files {
+			if slices.Contains(dependencyFiles, path.Base(file.Path)) {
+				return "update dependencies"
+			}
+		}
+	case kindTest:
+		added := make([]string, 0)
+		for _, file := range files {
+			added = append(added, newFuncs(file)...)
+		}
+		if len(added) > 0 {
+			return "add " + listNames(added)
+		}
+		return "update tests" + inFiles(files)
+	case kindDocs:
+		if len(files) == 1 {
+			return fileSubject(files[0])
+		}
+		return "update documentation"
+	}
+
+	if len(files) == 1 {
+		return fileSubject(files[0])
+	}
+
+	var added, removed, renamed, deleted []string
+	for _, file := range files {
+		added = append(added, newFuncs(file)...)
+		removed = append(removed, goneFuncs(file)...)
+		switch file.Status {
+		case changes.FileRenamed:
+			renamed = append(renamed, path.Base(file.OldPath))
+		case changes.FileDeleted:
+			deleted = append(deleted, path.Base(file.Path))
+		}
+	}
+	switch {
+	case len(added) > 0:
+		return "add " + listNames(added)
+	case len(removed) > 0:
+		return "remove " + listNames(removed)
+	case len(renamed) == len(files):
+		return "rename " + listNames(renamed)
+	case len(deleted) == len(files):
+		return "remove " + listNames(deleted)
+	}
+	return fmt.Sprintf("update %d files", len(files))
+}
+
+func fileSubject(file changes.FileDiff) string {
+	name := path.Base(file.Path)
+	switch {
+	case file.Status == changes.FileAdded:
+		return "add " + name
+	case file.Status == changes.FileDeleted:
+		return "remove " + name
+	case file.Status == changes.FileRenamed && file.Added == 0 && file.Deleted == 0:
+		return fmt.Sprintf("rename %s to %s", path.Base(file.OldPath), name)
+	case len(newFuncs(file)) > 0:
+		return "add " + listNames(newFuncs(file))
+	case len(goneFuncs(file)) > 0:
+		return "remove " + listNames(goneFuncs(file))
+	case len(file.AddedFuncs) > 0:
+		return "update " + listNames(file.AddedFuncs)
+	}
+	return "update " + name
+}
+
+// newFuncs are declared on added lines only, goneFuncs on removed lines only;
+// functions on both sides changed their signature
+func newFuncs(file changes.FileDiff) []string {
+	return subtract(file.AddedFuncs, file.RemovedFuncs)
+}
+
+func goneFuncs(file changes.FileDiff) []string {
+	return subtract(file.RemovedFuncs, file.AddedFuncs)
+}
+
+func subtract(names, other []string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if !slices.Contains(other, name) && !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func listNames(names []string) string {
+	if len(names) > heuristicMaxFuncs {
+		return fmt.Sprintf("%s and %d more", strings.Join(names[:heuristicMaxFuncs], ", "), len(names)-heuristicMaxFuncs)
+	}
+	return strings.Join(names, " and ")
+}
+
+func inFiles(files []changes.FileDiff) string {
+	if len(files) == 1 {
+		return " in " + path.Base(files[0].Path)
+	}
+	return ""
+}
+
+// formatHeader puts the breaking change marker after the scope, as the convention expects
+func formatHeader(commitType, scope string, breaking bool, subject string) string {
+	header := message.Header{Type: commitType, Scope: scope, Breaking: breaking}
+	prefix := header.String()
+	header.Description = truncateWords(subject, message.BodyWidth-len(prefix))
+	return header.String()
+}
+
+// truncateWords cuts text to at most width bytes at a word boundary,
+// a single word longer than width is cut between runes
+func truncateWords(text string, width int) string {
+	if len(text) <= width {
+		return text
+	}
+	result := ""
+	for _, word := range strings.Fields(text) {
+		next := word
+		if result != "" {
+			next = result + " " + word
+		}
+		if len(next) > width {
+			break
+		}
+		result = next
+	}
+	if result != "" {
+		return result
+	}
+	for i := range text {
+		if i > width {
+			break
+		}
+		result = text[:i]
+	}
+	return result
+}
+
+func fileListBody(files []changes.FileDiff) string {
+	lines := make([]string, 0, len(files))
+	for _, file := range files {
+		line := fmt.Sprintf("- %s %s (+%d -%d)", file.Status, file.Path, file.Added, file.Deleted)
+		if file.Status == changes.FileRenamed {
+			line = fmt.Sprintf("- renamed %s to %s (+%d -%d)", file.OldPath, file.Path, file.Added, file.Deleted)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -28,23 +28,27 @@ func NewLocalProvider(endpoint string, model string) *LocalProvider {
 	}
 }
 
-func (p *LocalProvider) GenerateCommitMessage(projectContext project.ProjectContext) (string, error) {
+func (p *LocalProvider) GenerateCommitMessage(projectContext project.ProjectContext, candidates int) (*Response, error) {
+	return repeatGenerate(candidates, func() (string, Usage, error) { return p.generate(projectContext) })
+}
+
+func (p *LocalProvider) generate(projectContext project.ProjectContext) (string, Usage, error) {
 	// Prepare request body
 	requestBody, err := json.Marshal(map[string]any{
 		"model":  p.model,
 		"prompt": generatePrompt(projectContext),
 	})
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 
 	// Send request to local AI
 	resp, err := http.Post(p.endpoint, "application/json", bytes.NewBuffer(requestBody))
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("error responce from ollama: %s", resp.Status)
+		return "", Usage{}, fmt.Errorf("error responce from ollama: %s", resp.Status)
 	}
 
 	// nolint
@@ -53,7 +57,7 @@ func (p *LocalProvider) GenerateCommitMessage(projectContext project.ProjectCont
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 
 	return parseOllamaResponse(body)
@@ -63,11 +67,12 @@ func generatePrompt(projectContext project.ProjectContext) string {
 	return "\n" + projectContext.SystemPrompt + "\n\n" + projectContext.Context
 }
 
-func parseOllamaResponse(body []byte) (string, error) {
+func parseOllamaResponse(body []byte) (string, Usage, error) {
 	// Split the response by newlines to get individual JSON objects
 	lines := strings.Split(string(body), "\n")
 
 	var fullResponse strings.Builder
+	var usage Usage
 
 	for _, line := range lines {
 		if line == "" {
@@ -77,7 +82,7 @@ func parseOllamaResponse(body []byte) (string, error) {
 		// Parse each JSON object
 		var respObj map[string]any
 		if err := json.Unmarshal([]byte(line), &respObj); err != nil {
-			return "", fmt.Errorf("failed to parse JSON: %w", err)
+			return "", Usage{}, fmt.Errorf("failed to parse JSON: %w", err)
 		}
 
 		// Extract the token from the "response" field
@@ -85,6 +90,13 @@ func parseOllamaResponse(body []byte) (string, error) {
 			fullResponse.WriteString(token)
 		}
 
+		// The final object carries the token counts
+		if count, ok := respObj["prompt_eval_count"].(float64); ok {
+			usage.PromptTokens = int(count)
+		}
+		if count, ok := respObj["eval_count"].(float64); ok {
+			usage.CompletionTokens = int(count)
+		}
 	}
-	return strings.TrimSpace(fullResponse.String()), nil
+	return strings.TrimSpace(fullResponse.String()), usage, nil
 }
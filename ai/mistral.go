@@ -21,13 +21,13 @@ func (p *MistralProvider) GetProviderInfo() ProviderInfo {
 }
 
 type mistralRequest struct {
-	Model       string    `json:"model"`
-	Messages    []message `json:"messages"`
-	Temperature float64   `json:"temperature"`
-	MaxTokens   int       `json:"max_tokens"`
+	Model       string           `json:"model"`
+	Messages    []mistralMessage `json:"messages"`
+	Temperature float64          `json:"temperature"`
+	MaxTokens   int              `json:"max_tokens"`
 }
 
-type message struct {
+type mistralMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
 }
@@ -38,6 +38,10 @@ type mistralResponse struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
 }
 
 func NewMistralProvider(apiKey string, model string) *MistralProvider {
@@ -50,10 +54,14 @@ func NewMistralProvider(apiKey string, model string) *MistralProvider {
 	}
 }
 
-func (p *MistralProvider) GenerateCommitMessage(projectContext project.ProjectContext) (string, error) {
+func (p *MistralProvider) GenerateCommitMessage(projectContext project.ProjectContext, candidates int) (*Response, error) {
+	return repeatGenerate(candidates, func() (string, Usage, error) { return p.generate(projectContext) })
+}
+
+func (p *MistralProvider) generate(projectContext project.ProjectContext) (string, Usage, error) {
 	req := mistralRequest{
 		Model: p.model,
-		Messages: []message{
+		Messages: []mistralMessage{
 			{
 				Role:    "system",
 				Content: projectContext.SystemPrompt,
@@ -64,17 +72,17 @@ func (p *MistralProvider) GenerateCommitMessage(projectContext project.ProjectCo
 			},
 		},
 		Temperature: 0.7,
-		MaxTokens:   50,
+		MaxTokens:   maxTokens(projectContext),
 	}
 
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %v", err)
+		return "", Usage{}, fmt.Errorf("error marshaling request: %v", err)
 	}
 
 	httpReq, err := http.NewRequest("POST", "https://api.mistral.ai/v1/chat/completions", bytes.NewBuffer(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %v", err)
+		return "", Usage{}, fmt.Errorf("error creating request: %v", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -83,24 +91,25 @@ func (p *MistralProvider) GenerateCommitMessage(projectContext project.ProjectCo
 	client := &http.Client{}
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("error making request: %v", err)
+		return "", Usage{}, fmt.Errorf("error making request: %v", err)
 	}
 	// nolint
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("error from Mistral API (status %d): %s", resp.StatusCode, string(body))
+		return "", Usage{}, fmt.Errorf("error from Mistral API (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var result mistralResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("error decoding response: %v", err)
+		return "", Usage{}, fmt.Errorf("error decoding response: %v", err)
 	}
 
 	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no response from Mistral API")
+		return "", Usage{}, fmt.Errorf("no response from Mistral API")
 	}
 
-	return result.Choices[0].Message.Content, nil
+	usage := Usage{PromptTokens: result.Usage.PromptTokens, CompletionTokens: result.Usage.CompletionTokens}
+	return result.Choices[0].Message.Content, usage, nil
 }
@@ -30,7 +30,7 @@ func (p *OpenAIProvider) GetProviderInfo() ProviderInfo {
 	return ProviderInfo{Name: "OpenAI", Model: p.Model}
 }
 
-func (p *OpenAIProvider) GenerateCommitMessage(projectContext project.ProjectContext) (string, error) {
+func (p *OpenAIProvider) GenerateCommitMessage(projectContext project.ProjectContext, candidates int) (*Response, error) {
 	resp, err := p.Client.CreateChatCompletion(
 		context.Background(),
 		openai.ChatCompletionRequest{
@@ -46,18 +46,24 @@ func (p *OpenAIProvider) GenerateCommitMessage(projectContext project.ProjectCon
 				},
 			},
 			Temperature: 0.7,
-			MaxTokens:   50,
+			MaxTokens:   maxTokens(projectContext),
+			N:           max(candidates, 1),
 		},
 	)
 	if err != nil {
-		return "", fmt.Errorf("error calling OpenAI API: %v", err)
+		return nil, fmt.Errorf("error calling OpenAI API: %v", err)
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI API")
+		return nil, fmt.Errorf("no response from OpenAI API")
 	}
 
-	return resp.Choices[0].Message.Content, nil
+	messages := make([]string, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		messages = append(messages, choice.Message.Content)
+	}
+	usage := Usage{PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens}
+	return newResponse(messages, usage)
 }
 
 func NewOpenAiProvider(baseURL string, apiKey string, model string) *OpenAIProvider {
@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/wert2all/ai-commit/project"
+)
+
+// Policy restricts what leaves the machine and where it is sent
+type Policy struct {
+	// ExcludePaths are never included in the context, only their names are
+	ExcludePaths []string `json:"exclude_paths"`
+	// LocalOnlyPaths may only be sent to the local provider
+	LocalOnlyPaths []string `json:"local_only_paths"`
+	// AllowedProviders limits the providers that can be selected, empty allows all
+	AllowedProviders []ProviderType `json:"allowed_providers"`
+}
+
+// Providers returns the provider types the policy allows, in ProviderTypes order
+func (p Policy) Providers() []ProviderType {
+	providers := make([]ProviderType, 0, len(ProviderTypes))
+	for _, providerType := range ProviderTypes {
+		if p.allows(providerType) {
+			providers = append(providers, providerType)
+		}
+	}
+	return providers
+}
+
+// checkChanges refuses remote providers when a changed path is local only
+func (p Policy) checkChanges(providerType ProviderType, projectContext project.ProjectContext) error {
+	if !providerType.IsRemote() || projectContext.Changes == nil {
+		return nil
+	}
+	for _, file := range projectContext.Changes.Files() {
+		for _, path := range []string{file.Path, file.OldPath} {
+			if project.MatchAny(p.LocalOnlyPaths, path) {
+				return fmt.Errorf("changes to %s may only be sent to a local provider by policy, not to %s", path, providerType)
+			}
+		}
+	}
+	return nil
+}
+
+func (p Policy) checkProvider(providerType ProviderType) error {
+	if p.allows(providerType) {
+		return nil
+	}
+	allowed := make([]string, 0, len(p.AllowedProviders))
+	for _, providerType := range p.AllowedProviders {
+		allowed = append(allowed, string(providerType))
+	}
+	return fmt.Errorf("provider %s is not allowed by policy, use one of: %s", providerType, strings.Join(allowed, ", "))
+}
+
+func (p Policy) allows(providerType ProviderType) bool {
+	return len(p.AllowedProviders) == 0 || slices.Contains(p.AllowedProviders, providerType)
+}
@@ -0,0 +1,82 @@
+package ai
+
+import "strings"
+
+// Price is the cost in USD per million tokens
+type Price struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// defaultPrices are list prices, matched by exact model name or the longest prefix.
+// They drift over time, override them with "prices" in the config file.
+var defaultPrices = map[string]Price{
+	"gpt-3.5-turbo":     {Prompt: 0.50, Completion: 1.50},
+	"gpt-4":             {Prompt: 30.00, Completion: 60.00},
+	"gpt-4-turbo":       {Prompt: 10.00, Completion: 30.00},
+	"gpt-4o":            {Prompt: 2.50, Completion: 10.00},
+	"gpt-4o-mini":       {Prompt: 0.15, Completion: 0.60},
+	"gpt-4.1":           {Prompt: 2.00, Completion: 8.00},
+	"gpt-4.1-mini":      {Prompt: 0.40, Completion: 1.60},
+	"gpt-4.1-nano":      {Prompt: 0.10, Completion: 0.40},
+	"claude-3-haiku":    {Prompt: 0.25, Completion: 1.25},
+	"claude-3-5-haiku":  {Prompt: 0.80, Completion: 4.00},
+	"claude-3-5-sonnet": {Prompt: 3.00, Completion: 15.00},
+	"claude-3-7-sonnet": {Prompt: 3.00, Completion: 15.00},
+	"claude-sonnet-4":   {Prompt: 3.00, Completion: 15.00},
+	"claude-3-opus":     {Prompt: 15.00, Completion: 75.00},
+	"claude-opus-4":     {Prompt: 15.00, Completion: 75.00},
+	"codestral":         {Prompt: 0.30, Completion: 0.90},
+	"mistral-small":     {Prompt: 0.10, Completion: 0.30},
+	"mistral-medium":    {Prompt: 0.40, Completion: 2.00},
+	"mistral-large":     {Prompt: 2.00, Completion: 6.00},
+	"gemini-1.5-flash":  {Prompt: 0.075, Completion: 0.30},
+	"gemini-1.5-pro":    {Prompt: 1.25, Completion: 5.00},
+	"gemini-2.0-flash":  {Prompt: 0.10, Completion: 0.40},
+	"gemini-2.5-flash":  {Prompt: 0.30, Completion: 2.50},
+	"gemini-2.5-pro":    {Prompt: 1.25, Completion: 10.00},
+}
+
+// EstimateCost returns the cost of the usage in USD and whether the model price is known.
+// Local models are free; overrides take precedence over the built-in table.
+func EstimateCost(providerType ProviderType, model string, usage Usage, overrides map[string]Price) (float64, bool) {
+	if !providerType.IsRemote() {
+		return 0, true
+	}
+
+	price, ok := lookupPrice(model, overrides)
+	if !ok {
+		// OpenRouter models are namespaced by vendor, e.g. "openai/gpt-4o"
+		if _, name, found := strings.Cut(model, "/"); found {
+			if strings.HasSuffix(name, ":free") {
+				return 0, true
+			}
+			price, ok = lookupPrice(name, overrides)
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+
+	cost := float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion
+	return cost / 1_000_000, true
+}
+
+func lookupPrice(model string, overrides map[string]Price) (Price, bool) {
+	for _, prices := range []map[string]Price{overrides, defaultPrices} {
+		if price, ok := prices[model]; ok {
+			return price, true
+		}
+
+		best := ""
+		for name := range prices {
+			if strings.HasPrefix(model, name) && len(name) > len(best) {
+				best = name
+			}
+		}
+		if best != "" {
+			return prices[best], true
+		}
+	}
+	return Price{}, false
+}
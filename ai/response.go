@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wert2all/ai-commit/message"
+)
+
+type (
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	}
+	Response struct {
+		Messages []string
+		Usage    Usage
+		// Cached is set when the messages come from the response cache and cost nothing
+		Cached bool
+		// Failures are the errors of fallback chain providers asked before the one that answered
+		Failures []string
+	}
+)
+
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+func (u Usage) add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+	}
+}
+
+// newResponse cleans the generated messages and drops duplicates
+func newResponse(messages []string, usage Usage) (*Response, error) {
+	seen := make(map[string]struct{}, len(messages))
+	unique := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		cleaned := message.Clean(msg)
+		if cleaned == "" {
+			continue
+		}
+		key := strings.ToLower(strings.Join(strings.Fields(cleaned), " "))
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, cleaned)
+	}
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("provider returned an empty commit message")
+	}
+	return &Response{Messages: unique, Usage: usage}, nil
+}
+
+// repeatGenerate collects candidates from providers without native support for alternatives
+func repeatGenerate(candidates int, generate func() (string, Usage, error)) (*Response, error) {
+	messages := make([]string, 0, candidates)
+	var usage Usage
+	for range max(candidates, 1) {
+		msg, callUsage, err := generate()
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+		usage = usage.add(callUsage)
+	}
+	return newResponse(messages, usage)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/branch"
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/ledger"
+	"github.com/wert2all/ai-commit/message"
+	"github.com/wert2all/ai-commit/project"
+	"github.com/wert2all/ai-commit/ui"
+)
+
+const branchUsage = "usage: ai-commit branch [--ticket <id>] [--create] [--print-only] [--output json]"
+
+type jsonBranch struct {
+	branch.Name
+	Current       string          `json:"current"`
+	DefaultBranch string          `json:"default_branch"`
+	OnDefault     bool            `json:"on_default"`
+	Staged        bool            `json:"staged"`
+	Created       bool            `json:"created"`
+	Provider      ai.ProviderInfo `json:"provider"`
+}
+
+// runBranch suggests a branch name for the staged changes, or the unstaged ones when
+// nothing is staged, and creates it with --create
+func runBranch(config ai.Config) error {
+	if len(config.Args) > 1 {
+		return errors.New(branchUsage)
+	}
+	staged := true
+	branchChanges, err := changes.NewChanges(config.Directory)
+	if errors.Is(err, changes.ErrNoChanges) {
+		staged = false
+		branchChanges, err = changes.NewUnstagedChanges(config.Directory)
+	}
+	if err != nil {
+		return err
+	}
+
+	provider, err := ai.NewProvider(config)
+	if err != nil {
+		return withExitCode(exitProvider, err)
+	}
+	contextBuilder, err := project.NewBuilder(config.Directory)
+	if err != nil {
+		return err
+	}
+	contextBuilder.ExcludePaths(config.File.Policy.ExcludePaths)
+	contextBuilder.AddRedaction(config.File.Redaction)
+	contextBuilder.AddLanguages()
+	contextBuilder.AddGitBranch()
+	contextBuilder.AddScopes(config.File.Scopes)
+	contextBuilder.AddBranchName(branchChanges)
+	projectContext, err := contextBuilder.Build()
+	if err != nil {
+		return err
+	}
+
+	generated, responses, err := roundTrip(config, provider, projectContext, 1)
+	if err != nil {
+		return err
+	}
+	msg := message.Parse(responses[0])
+	if header, ok := message.ParseHeader(msg.Subject); ok && header.Scope == "" && len(projectContext.Scopes) > 0 {
+		msg = msg.WithScope(projectContext.Scopes[0])
+	}
+	generated.candidates = append(generated.candidates, msg)
+
+	name := config.File.Branch.FromSubject(msg.Subject, config.Options.Ticket)
+	if err := branch.Check(config.Directory, name.Name); err != nil {
+		recordRun(config, generated, ledger.OutcomeRejected, name.Name)
+		return withExitCode(exitValidation, err)
+	}
+
+	report := jsonBranch{Name: name, Current: projectContext.Branch, Staged: staged, Provider: generated.providerInfo}
+	if base, err := changes.DefaultBase(config.Directory); err == nil {
+		report.DefaultBranch = strings.TrimPrefix(base, "origin/")
+	}
+	report.OnDefault = report.Current != "" && report.Current == report.DefaultBranch
+
+	if config.Options.Create {
+		if err := branch.Create(config.Directory, name.Name); err != nil {
+			recordRun(config, generated, ledger.OutcomeRejected, name.Name)
+			return err
+		}
+		report.Created = true
+		recordRun(config, generated, ledger.OutcomeAccepted, name.Name)
+	} else {
+		recordRun(config, generated, ledger.OutcomeGenerated, name.Name)
+	}
+
+	if jsonOutput {
+		return printJSON(report)
+	}
+	if report.OnDefault && !report.Created {
+		fmt.Fprintf(os.Stderr, "ai-commit: you are on the default branch %s, use --create to switch to the suggested branch before committing\n", report.Current)
+	}
+	if config.Options.PrintOnly {
+		fmt.Println(name.Name)
+		return nil
+	}
+
+	printFailures(generated)
+	fmt.Println(ui.NewProviderInfo(generated.providerInfo))
+	fmt.Println(ui.NewUsageInfo(generated.usage, generated.cost, generated.costKnown, generated.cached))
+	if len(projectContext.Redactions) > 0 {
+		fmt.Println(ui.NewRedactionInfo(projectContext.Redactions))
+	}
+	title := "Branch name for the staged changes"
+	if !staged {
+		title = "Branch name for the unstaged changes"
+	}
+	fmt.Println(ui.NewCard(title, name.Name, cardWidth))
+	if report.Created {
+		fmt.Printf("Switched to a new branch %s.\n", name.Name)
+	}
+	return nil
+}
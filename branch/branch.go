@@ -0,0 +1,118 @@
+package branch
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/wert2all/ai-commit/message"
+)
+
+// DefaultPattern names branches like feat/ABC-123-add-dark-mode
+const DefaultPattern = "<type>/<ticket>-<slug>"
+
+// maxSlugLength keeps names readable in branch lists and prompts
+const maxSlugLength = 40
+
+var (
+	slugPattern      = regexp.MustCompile(`[^a-z0-9]+`)
+	ticketPattern    = regexp.MustCompile(`[^A-Za-z0-9_#-]+`)
+	separatorPattern = regexp.MustCompile(`[-_/.]{2,}`)
+
+	fillerWords = []string{"a", "an", "and", "the", "to", "of", "for", "in", "on", "with", "or"}
+)
+
+type (
+	// Config describes how branch names are built
+	Config struct {
+		// Pattern may use the <type>, <scope>, <ticket> and <slug> placeholders
+		Pattern string `json:"pattern"`
+	}
+	// Name is a suggested branch name and the parts it was built from
+	Name struct {
+		Name   string `json:"name"`
+		Type   string `json:"type"`
+		Scope  string `json:"scope"`
+		Ticket string `json:"ticket"`
+		Slug   string `json:"slug"`
+	}
+)
+
+// FromSubject builds a branch name from a commit subject describing the changes.
+// Placeholders without a value are dropped together with their separator.
+func (c Config) FromSubject(subject, ticket string) Name {
+	name := Name{Ticket: ticketPattern.ReplaceAllString(strings.TrimSpace(ticket), "-")}
+	description := subject
+	if header, ok := message.ParseHeader(subject); ok {
+		name.Type = strings.ToLower(header.Type)
+		// several scopes do not fit in a branch name, the first one is the most changed
+		name.Scope = Slug(strings.Split(header.Scope, ",")[0])
+		description = header.Description
+	}
+	name.Slug = Slug(description)
+
+	pattern := c.Pattern
+	if pattern == "" {
+		pattern = DefaultPattern
+	}
+	replacer := strings.NewReplacer("<type>", name.Type, "<scope>", name.Scope, "<ticket>", name.Ticket, "<slug>", name.Slug)
+	result := replacer.Replace(pattern)
+	result = separatorPattern.ReplaceAllStringFunc(result, func(separators string) string {
+		return separators[:1]
+	})
+	name.Name = strings.Trim(result, "-_/.")
+	return name
+}
+
+// Slug turns a description into lower case words joined by hyphens, cut at a word boundary
+func Slug(description string) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(description), "-"), "-")
+	if len(slug) <= maxSlugLength {
+		return slug
+	}
+	words := strings.Split(slug[:maxSlugLength+1], "-")
+	if len(words) == 1 {
+		return slug[:maxSlugLength]
+	}
+	// the last word is cut or empty
+	words = words[:len(words)-1]
+	// a cut slug should not end in the middle of a phrase
+	for len(words) > 1 && slices.Contains(fillerWords, words[len(words)-1]) {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, "-")
+}
+
+// Check validates the name with git check-ref-format
+func Check(dir, name string) error {
+	if _, err := git(dir, "check-ref-format", "--branch", name); err != nil {
+		return fmt.Errorf("%q is not a valid branch name", name)
+	}
+	return nil
+}
+
+// Create creates the branch at HEAD and switches to it, keeping the index and working tree
+func Create(dir, name string) error {
+	if _, err := git(dir, "switch", "-c", name); err != nil {
+		return fmt.Errorf("error creating branch %s: %v", name, err)
+	}
+	return nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if text := strings.TrimSpace(stderr.String()); text != "" {
+			return "", fmt.Errorf("%s", text)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wert2all/ai-commit/ai"
+)
+
+const cacheUsage = "usage: ai-commit cache clear"
+
+func runCache(config ai.Config) error {
+	if len(config.Args) < 2 || config.Args[1] != "clear" {
+		return errors.New(cacheUsage)
+	}
+
+	removed, err := ai.ClearCache()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(map[string]int{"removed": removed})
+	}
+	fmt.Printf("Removed %d cached responses.\n", removed)
+	return nil
+}
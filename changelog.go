@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/changelog"
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/ledger"
+	"github.com/wert2all/ai-commit/project"
+)
+
+const changelogUsage = "usage: ai-commit changelog [--from <rev>] [--to <rev>] [--format markdown|keepachangelog] [--release <version>] [--notes] [--file CHANGELOG.md]"
+
+type jsonChangelog struct {
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	Release  string              `json:"release"`
+	Sections []changelog.Section `json:"sections"`
+	Markdown string              `json:"markdown"`
+}
+
+// runChangelog renders the Conventional Commits of a range as a changelog section
+func runChangelog(config ai.Config) error {
+	options := config.Options
+	if len(config.Args) > 1 || !slices.Contains(changelog.Formats, options.Format) {
+		return errors.New(changelogUsage)
+	}
+	if options.Notes {
+		var err error
+		if config, err = structuredResponse(config); err != nil {
+			return err
+		}
+	}
+
+	from := options.From
+	if from == "" {
+		from = changes.LatestTag(config.Directory, options.To)
+	}
+	release := options.Release
+	if release == "" {
+		release = changelog.Unreleased
+		if options.To != "HEAD" {
+			release = options.To
+		}
+	}
+
+	commits, err := changes.Commits(config.Directory, from, options.To)
+	if err != nil {
+		return withExitCode(exitNoChanges, err)
+	}
+	sections := changelog.Group(changelog.Parse(commits), options.Format)
+	if options.Notes && len(sections) > 0 {
+		if err := writeReleaseNotes(config, sections); err != nil {
+			return err
+		}
+	}
+	section := changelog.Render(release, time.Now(), sections, options.Format)
+
+	if options.File != "" {
+		if err := insertChangelog(config, section); err != nil {
+			return err
+		}
+	}
+	switch {
+	case jsonOutput:
+		return printJSON(jsonChangelog{From: from, To: options.To, Release: release, Sections: sections, Markdown: section})
+	case options.File != "":
+		fmt.Printf("Added %s to %s.\n", release, options.File)
+	default:
+		fmt.Print(section)
+	}
+	return nil
+}
+
+// writeReleaseNotes asks the provider to rewrite the entries of each section for users
+func writeReleaseNotes(config ai.Config, sections []changelog.Section) error {
+	provider, err := ai.NewProvider(config)
+	if err != nil {
+		return withExitCode(exitProvider, err)
+	}
+	contextBuilder, err := project.NewBuilder(config.Directory)
+	if err != nil {
+		return err
+	}
+	contextBuilder.AddRedaction(config.File.Redaction)
+	contextBuilder.AddReleaseNotes(changelog.Render(changelog.Unreleased, time.Now(), sections, changelog.FormatMarkdown))
+	projectContext, err := contextBuilder.Build()
+	if err != nil {
+		return err
+	}
+
+	generated, responses, err := roundTrip(config, provider, projectContext, 1)
+	if err != nil {
+		return err
+	}
+	recordRun(config, generated, ledger.OutcomeGenerated, "release notes")
+	if !jsonOutput {
+		printFailures(generated)
+	}
+
+	raw := responses[0]
+	start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}")
+	if start < 0 || end < start {
+		return withExitCode(exitProvider, errors.New("provider did not return release notes"))
+	}
+	var notes map[string][]string
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &notes); err != nil {
+		return withExitCode(exitProvider, fmt.Errorf("error parsing release notes: %v", err))
+	}
+	// sections the model left out keep their entries
+	for i := range sections {
+		sections[i].Notes = notes[sections[i].Title]
+	}
+	return nil
+}
+
+// insertChangelog adds the section to --file, relative paths are relative to the repository root
+func insertChangelog(config ai.Config, section string) error {
+	path := config.Options.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(config.RepoRoot, path)
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(changelog.Insert(string(existing), section)), 0o644); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
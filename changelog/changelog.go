@@ -0,0 +1,282 @@
+package changelog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/message"
+)
+
+const (
+	// FormatMarkdown follows conventional-changelog and release-please
+	FormatMarkdown = "markdown"
+	// FormatKeepAChangelog follows https://keepachangelog.com
+	FormatKeepAChangelog = "keepachangelog"
+
+	Unreleased = "Unreleased"
+
+	shortHashLength = 7
+)
+
+// Formats are the supported changelog formats
+var Formats = []string{FormatMarkdown, FormatKeepAChangelog}
+
+type (
+	// Entry is a commit as it appears in the changelog
+	Entry struct {
+		Type        string `json:"type"`
+		Scope       string `json:"scope"`
+		Description string `json:"description"`
+		Hash        string `json:"hash"`
+		Breaking    bool   `json:"breaking"`
+		// BreakingNote is the BREAKING CHANGE footer, if any
+		BreakingNote string `json:"breaking_note,omitempty"`
+	}
+	// Section groups entries under a heading, Notes replace the entries when written by a model
+	Section struct {
+		Title   string   `json:"title"`
+		Entries []Entry  `json:"entries"`
+		Notes   []string `json:"notes,omitempty"`
+	}
+	// heading maps commit types to a section title, in the order sections are rendered
+	heading struct {
+		title string
+		types []string
+	}
+)
+
+var (
+	markdownHeadings = []heading{
+		{title: "Features", types: []string{"feat"}},
+		{title: "Bug Fixes", types: []string{"fix"}},
+		{title: "Performance Improvements", types: []string{"perf"}},
+		{title: "Reverts", types: []string{"revert"}},
+		{title: "Other Changes", types: []string{""}},
+	}
+	keepAChangelogHeadings = []heading{
+		{title: "Added", types: []string{"feat"}},
+		{title: "Changed", types: []string{"perf", "refactor", ""}},
+		{title: "Deprecated"},
+		{title: "Removed", types: []string{"revert"}},
+		{title: "Fixed", types: []string{"fix"}},
+		{title: "Security"},
+	}
+	// hiddenTypes do not change what users get, unless they break something
+	hiddenTypes = []string{"docs", "style", "test", "build", "ci", "chore"}
+)
+
+const breakingTitle = "⚠ BREAKING CHANGES"
+
+// Parse turns commits into entries, commits that do not follow the convention get an empty type
+func Parse(commits []changes.Commit) []Entry {
+	entries := make([]Entry, 0, len(commits))
+	for _, commit := range commits {
+		msg := message.Parse(commit.Subject + "\n\n" + commit.Body)
+		entry := Entry{Description: commit.Subject, Hash: commit.Hash, Breaking: msg.IsBreaking()}
+		if header, ok := message.ParseHeader(commit.Subject); ok {
+			entry.Type = strings.ToLower(header.Type)
+			entry.Scope = header.Scope
+			entry.Description = header.Description
+		}
+		for _, footer := range msg.Footers {
+			if footer.IsBreaking() {
+				entry.BreakingNote = footer.Value
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Group sorts entries into the sections of the format, empty sections are left out
+func Group(entries []Entry, format string) []Section {
+	headings := markdownHeadings
+	if format == FormatKeepAChangelog {
+		headings = keepAChangelogHeadings
+	}
+
+	sections := make([]Section, len(headings))
+	for i, heading := range headings {
+		sections[i].Title = heading.title
+	}
+	breaking := Section{Title: breakingTitle}
+	for _, entry := range entries {
+		if entry.Breaking && format == FormatMarkdown {
+			breaking.Entries = append(breaking.Entries, entry)
+		}
+		if slices.Contains(hiddenTypes, entry.Type) && !entry.Breaking {
+			continue
+		}
+		i := sectionOf(headings, entry)
+		sections[i].Entries = append(sections[i].Entries, entry)
+	}
+
+	result := make([]Section, 0, len(sections)+1)
+	if len(breaking.Entries) > 0 {
+		result = append(result, breaking)
+	}
+	for _, section := range sections {
+		if len(section.Entries) > 0 {
+			result = append(result, section)
+		}
+	}
+	return result
+}
+
+// sectionOf finds the heading of an entry, Keep a Changelog also looks at the wording
+func sectionOf(headings []heading, entry Entry) int {
+	description := strings.ToLower(entry.Description)
+	for i, heading := range headings {
+		switch {
+		case heading.title == "Security" && entry.Scope == "security":
+			return i
+		case heading.title == "Deprecated" && strings.HasPrefix(description, "deprecate"):
+			return i
+		case heading.title == "Removed" && (strings.HasPrefix(description, "remove") || strings.HasPrefix(description, "drop")):
+			return i
+		}
+	}
+	for i, heading := range headings {
+		if slices.Contains(heading.types, entry.Type) {
+			return i
+		}
+	}
+	// types without a heading of their own, such as breaking docs changes
+	for i, heading := range headings {
+		if slices.Contains(heading.types, "") {
+			return i
+		}
+	}
+	return len(headings) - 1
+}
+
+// Render formats a release section, release is a version or Unreleased
+func Render(release string, date time.Time, sections []Section, format string) string {
+	var result strings.Builder
+	switch {
+	case format == FormatKeepAChangelog && release == Unreleased:
+		result.WriteString("## [Unreleased]\n")
+	case release == Unreleased:
+		result.WriteString("## Unreleased\n")
+	case format == FormatKeepAChangelog:
+		result.WriteString(fmt.Sprintf("## [%s] - %s\n", strings.TrimPrefix(release, "v"), date.Format(time.DateOnly)))
+	default:
+		result.WriteString(fmt.Sprintf("## %s (%s)\n", release, date.Format(time.DateOnly)))
+	}
+
+	for _, section := range sections {
+		result.WriteString("\n### " + section.Title + "\n\n")
+		if len(section.Notes) > 0 {
+			for _, note := range section.Notes {
+				result.WriteString("- " + strings.TrimSpace(strings.TrimLeft(note, "-* ")) + "\n")
+			}
+			continue
+		}
+		for _, entry := range section.Entries {
+			result.WriteString(renderEntry(entry, section.Title == breakingTitle, format) + "\n")
+		}
+	}
+	return result.String()
+}
+
+func renderEntry(entry Entry, breakingSection bool, format string) string {
+	description := entry.Description
+	if breakingSection && entry.BreakingNote != "" {
+		description = entry.BreakingNote
+	}
+	line := "- "
+	if entry.Scope != "" {
+		line += "**" + entry.Scope + ":** "
+	}
+	if format == FormatKeepAChangelog && entry.Breaking {
+		line += "**BREAKING:** "
+	}
+	line += description
+	if entry.Hash != "" {
+		line += " (" + entry.Hash[:min(shortHashLength, len(entry.Hash))] + ")"
+	}
+	return line
+}
+
+// Insert puts a release section above the newest release of an existing changelog,
+// or starts a new changelog when existing is empty. A section of the same version,
+// such as Unreleased or a release generated again on a later day, is replaced.
+// The Unreleased section stays on top as Keep a Changelog asks; a new release takes
+// over its changes, so it is left empty.
+func Insert(existing, section string) string {
+	if strings.TrimSpace(existing) == "" {
+		return "# Changelog\n\n" + section
+	}
+	heading, _, _ := strings.Cut(section, "\n")
+	version := headingVersion(heading)
+	prelude, sections := splitSections(existing)
+	if len(sections) == 0 {
+		return strings.TrimRight(prelude, "\n") + "\n\n" + section
+	}
+
+	result := make([]string, 0, len(sections)+1)
+	inserted := false
+	for _, current := range sections {
+		currentHeading, _, _ := strings.Cut(current, "\n")
+		currentVersion := headingVersion(currentHeading)
+		switch {
+		case currentVersion == version:
+			if !inserted {
+				result = append(result, section)
+				inserted = true
+			}
+			continue
+		case currentVersion == Unreleased:
+			result = append(result, currentHeading+"\n")
+			continue
+		case !inserted:
+			result = append(result, section)
+			inserted = true
+		}
+		result = append(result, current)
+	}
+	if !inserted {
+		result = append(result, section)
+	}
+
+	for i, current := range result[:len(result)-1] {
+		// one blank line between sections
+		result[i] = strings.TrimRight(current, "\n") + "\n"
+	}
+	return prelude + strings.Join(result, "\n")
+}
+
+// splitSections cuts a changelog into the text before the first release and the releases
+func splitSections(changelog string) (string, []string) {
+	prelude := ""
+	sections := make([]string, 0)
+	for _, line := range strings.SplitAfter(changelog, "\n") {
+		switch {
+		case strings.HasPrefix(line, "## "):
+			sections = append(sections, line)
+		case len(sections) == 0:
+			prelude += line
+		default:
+			sections[len(sections)-1] += line
+		}
+	}
+	return prelude, sections
+}
+
+// headingVersion returns the version a release heading names without a v prefix, such as
+// 1.3.0 for "## [1.3.0] - 2026-02-01" or "## v1.3.0 (2026-02-01)", or Unreleased
+func headingVersion(heading string) string {
+	title := strings.TrimSpace(strings.TrimPrefix(heading, "## "))
+	if rest, ok := strings.CutPrefix(title, "["); ok {
+		title, _, _ = strings.Cut(rest, "]")
+	} else if fields := strings.Fields(title); len(fields) > 0 {
+		title = fields[0]
+	}
+	if strings.EqualFold(title, Unreleased) {
+		return Unreleased
+	}
+	return strings.TrimPrefix(title, "v")
+}
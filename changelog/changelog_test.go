@@ -0,0 +1,102 @@
+package changelog
+
+import "testing"
+
+const keepAChangelog = `# Changelog
+
+## [Unreleased]
+
+### Added
+
+- Dark mode
+
+## [1.2.0] - 2026-01-10
+
+### Fixed
+
+- Crash on start
+`
+
+func TestInsert(t *testing.T) {
+	release := "## [1.3.0] - 2026-02-01\n\n### Added\n\n- Export\n"
+	tests := []struct {
+		name, existing, section, want string
+	}{
+		{
+			name:    "new file",
+			section: release,
+			want:    "# Changelog\n\n" + release,
+		},
+		{
+			name:     "release below emptied unreleased",
+			existing: keepAChangelog,
+			section:  release,
+			want: `# Changelog
+
+## [Unreleased]
+
+## [1.3.0] - 2026-02-01
+
+### Added
+
+- Export
+
+## [1.2.0] - 2026-01-10
+
+### Fixed
+
+- Crash on start
+`,
+		},
+		{
+			name:     "unreleased replaced",
+			existing: keepAChangelog,
+			section:  "## [Unreleased]\n\n### Fixed\n\n- Typo\n",
+			want: `# Changelog
+
+## [Unreleased]
+
+### Fixed
+
+- Typo
+
+## [1.2.0] - 2026-01-10
+
+### Fixed
+
+- Crash on start
+`,
+		},
+		{
+			name:     "release after unreleased only",
+			existing: "# Changelog\n\n## Unreleased\n\n- Dark mode\n",
+			section:  release,
+			want:     "# Changelog\n\n## Unreleased\n\n" + release,
+		},
+		{
+			name:     "release generated again on a later day",
+			existing: "# Changelog\n\n## [Unreleased]\n\n## [1.3.0] - 2026-01-31\n\n### Added\n\n- Old\n\n## [1.2.0] - 2026-01-10\n",
+			section:  release,
+			want:     "# Changelog\n\n## [Unreleased]\n\n" + release + "\n## [1.2.0] - 2026-01-10\n",
+		},
+		{
+			name:     "markdown release replaced by version",
+			existing: "# Changelog\n\n## v1.3.0 (2026-01-31)\n\n- Old\n\n## v1.2.0 (2026-01-10)\n\n- Older\n",
+			section:  "## v1.3.0 (2026-02-01)\n\n- New\n",
+			want:     "# Changelog\n\n## v1.3.0 (2026-02-01)\n\n- New\n\n## v1.2.0 (2026-01-10)\n\n- Older\n",
+		},
+		{
+			name:     "no releases yet",
+			existing: "# Changelog\n\nAll notable changes.\n",
+			section:  release,
+			want:     "# Changelog\n\nAll notable changes.\n\n" + release,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Insert(test.existing, test.section); got != test.want {
+				t.Errorf("Insert() =\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package main
+
+import "testing"
+
+func TestChangelogNotesRefuseHeuristicProvider(t *testing.T) {
+	config := heuristicConfig(t, "changelog")
+	config.Options.Notes = true
+	assertHeuristicRefused(t, runChangelog(config))
+}
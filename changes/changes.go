@@ -1,29 +1,89 @@
 package changes
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
+	"regexp"
 	"strings"
 )
 
+// ErrNoChanges is returned when nothing is staged
+var ErrNoChanges = errors.New("no changes detected in the repository")
+
+// funcPattern matches function declarations of common languages, Go methods included
+var funcPattern = regexp.MustCompile(`^\s*(?:(?:export|pub(?:\([a-z]+\))?|public|private|protected|internal|static|async|override|default|final|abstract|open|suspend)\s+)*(?:func|function|def|fn|fun)\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w*)`)
+
 type (
 	Changes interface {
 		Diff() []byte
 		ChangedFiles() []string
+		Files() []FileDiff
+	}
+	FileStatus int
+	FileDiff   struct {
+		Path    string     `json:"path"`
+		OldPath string     `json:"old_path"`
+		Status  FileStatus `json:"status"`
+		Added   int        `json:"added"`
+		Deleted int        `json:"deleted"`
+		// AddedFuncs and RemovedFuncs are function declarations on added and removed lines
+		AddedFuncs   []string `json:"added_funcs,omitempty"`
+		RemovedFuncs []string `json:"removed_funcs,omitempty"`
 	}
 	changesImpl struct {
 		changed      []byte
 		changedFiles []string
+		files        []FileDiff
 	}
 )
 
+const (
+	FileModified FileStatus = iota
+	FileAdded
+	FileDeleted
+	FileRenamed
+)
+
+func (s FileStatus) String() string {
+	switch s {
+	case FileAdded:
+		return "new file"
+	case FileDeleted:
+		return "deleted"
+	case FileRenamed:
+		return "renamed"
+	default:
+		return "modified"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s FileStatus) MarshalText() ([]byte, error) {
+	switch s {
+	case FileAdded:
+		return []byte("added"), nil
+	case FileDeleted:
+		return []byte("deleted"), nil
+	case FileRenamed:
+		return []byte("renamed"), nil
+	default:
+		return []byte("modified"), nil
+	}
+}
+
 // Diff implements Changes.
 func (c *changesImpl) Diff() []byte { return c.changed }
 
 func (c *changesImpl) ChangedFiles() []string { return c.changedFiles }
 
-func NewChanges() (Changes, error) {
+// Files implements Changes.
+func (c *changesImpl) Files() []FileDiff { return c.files }
+
+// NewChanges reads the staged changes of the repository in dir
+func NewChanges(dir string) (Changes, error) {
 	changedCmd := exec.Command("git", "diff", "--cached", "--diff-algorithm=minimal")
+	changedCmd.Dir = dir
 	changes, err := changedCmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("error getting staged changes: %v", err)
@@ -31,7 +91,7 @@ func NewChanges() (Changes, error) {
 
 	// If no changes, return error
 	if strings.TrimSpace(string(changes[:])) == "" {
-		return nil, fmt.Errorf("no changes detected in the repository")
+		return nil, ErrNoChanges
 	}
 
 	// Extract changed files from the diff output
@@ -40,6 +100,7 @@ func NewChanges() (Changes, error) {
 	return &changesImpl{
 		changed:      changes,
 		changedFiles: changedFiles,
+		files:        parseFileDiffs(changes),
 	}, nil
 }
 
@@ -67,3 +128,42 @@ func extractChangedFilesFromDiff(diff []byte) []string {
 	}
 	return files
 }
+
+// parseFileDiffs counts added and deleted lines per file in diff order.
+func parseFileDiffs(diff []byte) []FileDiff {
+	files := make([]FileDiff, 0)
+	var current *FileDiff
+	inHunk := false
+	for line := range strings.SplitSeq(string(diff), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			parts := strings.Split(line, " ")
+			path := strings.TrimPrefix(parts[len(parts)-1], "b/")
+			files = append(files, FileDiff{Path: path, OldPath: path})
+			current = &files[len(files)-1]
+			inHunk = false
+		case current == nil:
+		case !inHunk && strings.HasPrefix(line, "new file mode"):
+			current.Status = FileAdded
+		case !inHunk && strings.HasPrefix(line, "deleted file mode"):
+			current.Status = FileDeleted
+		case !inHunk && strings.HasPrefix(line, "rename from "):
+			current.Status = FileRenamed
+			current.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+		case strings.HasPrefix(line, "+"):
+			current.Added++
+			if match := funcPattern.FindStringSubmatch(line[1:]); match != nil {
+				current.AddedFuncs = append(current.AddedFuncs, match[1])
+			}
+		case strings.HasPrefix(line, "-"):
+			current.Deleted++
+			if match := funcPattern.FindStringSubmatch(line[1:]); match != nil {
+				current.RemovedFuncs = append(current.RemovedFuncs, match[1])
+			}
+		}
+	}
+	return files
+}
@@ -0,0 +1,110 @@
+package changes
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Hunk is the smallest part of the staged changes that can be staged on its own.
+// Modified files are split at their @@ hunks; added, deleted, renamed and binary files are one hunk.
+type Hunk struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+	// Range is the @@ line of the hunk, empty when the whole file is one hunk
+	Range string `json:"range"`
+	// header is the diff header of the file, body the hunk lines including the @@ line
+	header string
+	body   string
+}
+
+// StagedHunks reads the staged changes as hunks, binary patches included so they can be applied again
+func StagedHunks(dir string) ([]Hunk, error) {
+	cmd := exec.Command("git", "diff", "--cached", "--binary", "--no-color", "--no-ext-diff", "--diff-algorithm=minimal")
+	cmd.Dir = dir
+	diff, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting staged changes: %v", err)
+	}
+	if strings.TrimSpace(string(diff)) == "" {
+		return nil, ErrNoChanges
+	}
+	return ParseHunks(diff), nil
+}
+
+// ParseHunks splits a diff into hunks numbered H1, H2, ... in diff order
+func ParseHunks(diff []byte) []Hunk {
+	hunks := make([]Hunk, 0)
+	for _, file := range splitFiles(string(diff)) {
+		header, bodies := splitHunks(file)
+		path := parseFileDiffs([]byte(file))[0]
+		if path.Status != FileModified || len(bodies) < 2 {
+			hunks = append(hunks, Hunk{Path: path.Path, header: header, body: strings.Join(bodies, "")})
+			continue
+		}
+		for _, body := range bodies {
+			hunkRange, _, _ := strings.Cut(body, "\n")
+			hunks = append(hunks, Hunk{Path: path.Path, Range: hunkRange, header: header, body: body})
+		}
+	}
+	for i := range hunks {
+		hunks[i].ID = fmt.Sprintf("H%d", i+1)
+	}
+	return hunks
+}
+
+// Text is the hunk as shown to the model: the @@ lines, or the file header for whole files
+func (h Hunk) Text() string {
+	if h.body == "" {
+		// binary patch data means nothing to the model
+		header, _, _ := strings.Cut(h.header, "GIT binary patch\n")
+		return header
+	}
+	return h.body
+}
+
+// Patch joins hunks into a patch for git apply, hunks of one file under a single file header
+func Patch(hunks []Hunk) []byte {
+	var patch strings.Builder
+	header := ""
+	for _, hunk := range hunks {
+		if hunk.header != header {
+			header = hunk.header
+			patch.WriteString(header)
+		}
+		patch.WriteString(hunk.body)
+	}
+	return []byte(patch.String())
+}
+
+// splitFiles cuts a diff at its "diff --git" lines, keeping the trailing newlines
+func splitFiles(diff string) []string {
+	files := make([]string, 0)
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") || len(files) == 0 {
+			files = append(files, "")
+		}
+		files[len(files)-1] += line
+	}
+	if len(files) > 0 && !strings.HasPrefix(files[0], "diff --git ") {
+		files = files[1:]
+	}
+	return files
+}
+
+// splitHunks separates the file header from the @@ hunks
+func splitHunks(file string) (string, []string) {
+	header := ""
+	bodies := make([]string, 0)
+	for _, line := range strings.SplitAfter(file, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			bodies = append(bodies, line)
+		case len(bodies) == 0:
+			header += line
+		default:
+			bodies[len(bodies)-1] += line
+		}
+	}
+	return header, bodies
+}
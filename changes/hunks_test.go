@@ -0,0 +1,64 @@
+package changes
+
+import (
+	"strings"
+	"testing"
+)
+
+const twoFileDiff = `diff --git a/list.txt b/list.txt
+index 1111111..2222222 100644
+--- a/list.txt
++++ b/list.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++TWO
+ three
+@@ -20,3 +20,3 @@ nineteen
+ twenty
+-twenty-one
++TWENTY-ONE
+ twenty-two
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++first
++second
+`
+
+func TestParseHunks(t *testing.T) {
+	hunks := ParseHunks([]byte(twoFileDiff))
+	if len(hunks) != 3 {
+		t.Fatalf("got %d hunks, want 3", len(hunks))
+	}
+	want := []struct{ id, path, hunkRange string }{
+		{"H1", "list.txt", "@@ -1,3 +1,3 @@"},
+		{"H2", "list.txt", "@@ -20,3 +20,3 @@ nineteen"},
+		// added files are one hunk without a range
+		{"H3", "new.txt", ""},
+	}
+	for i, w := range want {
+		if hunks[i].ID != w.id || hunks[i].Path != w.path || hunks[i].Range != w.hunkRange {
+			t.Errorf("hunk %d is %s %s %q, want %s %s %q", i, hunks[i].ID, hunks[i].Path, hunks[i].Range, w.id, w.path, w.hunkRange)
+		}
+	}
+	if !strings.HasPrefix(hunks[2].Text(), "@@ -0,0 +1,2 @@\n+first") {
+		t.Errorf("unexpected text of an added file:\n%s", hunks[2].Text())
+	}
+}
+
+func TestPatchWritesOneHeaderPerFile(t *testing.T) {
+	hunks := ParseHunks([]byte(twoFileDiff))
+
+	if patch := string(Patch(hunks)); patch != twoFileDiff {
+		t.Errorf("patch of all hunks differs from the diff:\n%s", patch)
+	}
+
+	patch := string(Patch(hunks[1:2]))
+	if strings.Count(patch, "diff --git ") != 1 || strings.Contains(patch, "+TWO\n") || !strings.Contains(patch, "+TWENTY-ONE\n") {
+		t.Errorf("unexpected patch of the second hunk:\n%s", patch)
+	}
+}
@@ -0,0 +1,138 @@
+package changes
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoCommits is returned when a revision range contains no commits
+var ErrNoCommits = errors.New("no commits in the range")
+
+// Commit is a commit of a revision range
+type Commit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	// Message is the raw message, as validated against the convention
+	Message string `json:"-"`
+}
+
+// NewRangeChanges reads the changes between the merge base of base and head, and head,
+// as a pull request shows them
+func NewRangeChanges(dir, base, head string) (Changes, error) {
+	diff, err := gitOutput(dir, "diff", "--diff-algorithm=minimal", base+"..."+head)
+	if err != nil {
+		return nil, fmt.Errorf("error getting changes of %s..%s: %v", base, head, err)
+	}
+	if strings.TrimSpace(diff) == "" {
+		return nil, ErrNoChanges
+	}
+	return &changesImpl{
+		changed:      []byte(diff),
+		changedFiles: extractChangedFilesFromDiff([]byte(diff)),
+		files:        parseFileDiffs([]byte(diff)),
+	}, nil
+}
+
+// NewCommitChanges reads the changes a commit made to its first parent, root commits included
+func NewCommitChanges(dir, hash string) (Changes, error) {
+	diff, err := gitOutput(dir, "diff-tree", "-p", "-M", "--root", "--no-commit-id", "--diff-algorithm=minimal", hash)
+	if err != nil {
+		return nil, fmt.Errorf("error getting changes of %s: %v", hash, err)
+	}
+	if strings.TrimSpace(diff) == "" {
+		return nil, ErrNoChanges
+	}
+	return &changesImpl{
+		changed:      []byte(diff),
+		changedFiles: extractChangedFilesFromDiff([]byte(diff)),
+		files:        parseFileDiffs([]byte(diff)),
+	}, nil
+}
+
+// NewUnstagedChanges reads the changes of the working tree that are not staged yet
+func NewUnstagedChanges(dir string) (Changes, error) {
+	diff, err := gitOutput(dir, "diff", "--diff-algorithm=minimal")
+	if err != nil {
+		return nil, fmt.Errorf("error getting unstaged changes: %v", err)
+	}
+	if strings.TrimSpace(diff) == "" {
+		return nil, ErrNoChanges
+	}
+	return &changesImpl{
+		changed:      []byte(diff),
+		changedFiles: extractChangedFilesFromDiff([]byte(diff)),
+		files:        parseFileDiffs([]byte(diff)),
+	}, nil
+}
+
+// Commits lists the commits reachable from head but not from base, oldest first.
+// An empty base lists the whole history of head.
+func Commits(dir, base, head string) ([]Commit, error) {
+	revisions := head
+	if base != "" {
+		revisions = base + ".." + head
+	}
+	output, err := gitOutput(dir, "log", "--reverse", "--no-merges", "--format=%H%x00%s%x00%b%x00%B%x1e", revisions)
+	if err != nil {
+		return nil, fmt.Errorf("error listing commits of %s: %v", revisions, err)
+	}
+	commits := make([]Commit, 0)
+	for record := range strings.SplitSeq(output, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Subject: fields[1],
+			Body:    strings.TrimSpace(fields[2]),
+			Message: strings.TrimSpace(fields[3]),
+		})
+	}
+	if len(commits) == 0 {
+		return nil, ErrNoCommits
+	}
+	return commits, nil
+}
+
+// DefaultBase returns the default branch to compare against: the remote HEAD,
+// or main or master when the remote does not tell
+func DefaultBase(dir string) (string, error) {
+	if ref, err := gitOutput(dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimSpace(ref), nil
+	}
+	for _, branch := range []string{"origin/main", "origin/master", "main", "master"} {
+		if _, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", branch); err == nil {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find the default branch, give the base as base..HEAD")
+}
+
+// LatestTag returns the most recent tag before head, or an empty string without tags.
+// A tag on head itself is skipped, so a release is described from the previous one.
+func LatestTag(dir, head string) string {
+	// the parent of a root commit does not exist, which describes like no tags
+	tag, err := gitOutput(dir, "describe", "--tags", "--abbrev=0", head+"^")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(tag)
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(output), nil
+}
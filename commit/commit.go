@@ -3,33 +3,119 @@ package commit
 import (
 	"bufio"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 )
 
-func AskUser() bool {
-	// Ask user for confirmation
-	fmt.Print("Do you want to commit with this message? (yes/no): ")
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		log.Fatal("Error reading user input:", err)
+type (
+	Action int
+	Answer struct {
+		Action Action
+		// Choice is the zero-based index of the selected candidate
+		Choice int
+	}
+)
+
+const (
+	ActionCommit Action = iota
+	ActionCancel
+	ActionEdit
+	ActionRegenerate
+)
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// AskUser asks what to do with the generated candidates until a valid answer is given.
+// Closed input is treated as a cancelled commit.
+func AskUser(candidates int) Answer {
+	for {
+		// Ask user for confirmation
+		if candidates > 1 {
+			fmt.Printf("Choose a message to commit (1-%d), e<N> to edit, r to regenerate or no to cancel: ", candidates)
+		} else {
+			fmt.Print("Do you want to commit with this message? (yes/no, e to edit, r to regenerate): ")
+		}
+		response, err := stdin.ReadString('\n')
+		if err != nil {
+			fmt.Println()
+			return Answer{Action: ActionCancel}
+		}
+
+		if answer, ok := parseAnswer(strings.TrimSpace(strings.ToLower(response)), candidates); ok {
+			return answer
+		}
+		fmt.Println("Unknown answer, please try again.")
+	}
+}
+
+// Confirm asks a yes/no question, closed input answers no
+func Confirm(question string) bool {
+	for {
+		fmt.Printf("%s (yes/no): ", question)
+		response, err := stdin.ReadString('\n')
+		if err != nil {
+			fmt.Println()
+			return false
+		}
+		switch strings.TrimSpace(strings.ToLower(response)) {
+		case "yes", "y", "":
+			return true
+		case "no", "n":
+			return false
+		}
+		fmt.Println("Unknown answer, please try again.")
+	}
+}
+
+func parseAnswer(response string, candidates int) (Answer, bool) {
+	switch response {
+	case "yes", "y", "":
+		return Answer{Action: ActionCommit}, true
+	case "no", "n":
+		return Answer{Action: ActionCancel}, true
+	case "r":
+		return Answer{Action: ActionRegenerate}, true
+	case "e":
+		return Answer{Action: ActionEdit}, true
 	}
 
-	response = strings.TrimSpace(strings.ToLower(response))
-	return response == "yes" || response == "y" || response == ""
+	action := ActionCommit
+	if rest, ok := strings.CutPrefix(response, "e"); ok {
+		action = ActionEdit
+		response = rest
+	}
+	choice, err := strconv.Atoi(response)
+	if err != nil || choice < 1 || choice > candidates {
+		return Answer{}, false
+	}
+	return Answer{Action: action, Choice: choice - 1}, true
 }
 
-func Commit(commitMsg string, absProjectDir string) {
-	// Execute git commit with proper escaping of special characters
-	cmd := exec.Command("git", "commit", "-m", commitMsg)
+func Commit(commitMsg string, absProjectDir string) error {
+	// Write the message to a file so multi-line bodies and footers keep their formatting
+	msgFile, err := os.CreateTemp("", "ai-commit-*.txt")
+	if err != nil {
+		return fmt.Errorf("error creating commit message file: %v", err)
+	}
+	// nolint
+	defer os.Remove(msgFile.Name())
+
+	if _, err := msgFile.WriteString(commitMsg + "\n"); err != nil {
+		return fmt.Errorf("error writing commit message file: %v", err)
+	}
+	if err := msgFile.Close(); err != nil {
+		return fmt.Errorf("error writing commit message file: %v", err)
+	}
+
+	cmd := exec.Command("git", "commit", "-F", msgFile.Name())
 	cmd.Dir = absProjectDir
 	// Set the environment to ensure proper handling of special characters
 	cmd.Env = append(os.Environ(), "LANG=en_US.UTF-8")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Fatal("Error executing git commit:", string(output))
+		return fmt.Errorf("error executing git commit: %s", strings.TrimSpace(string(output)))
 	}
+	return nil
 }
@@ -0,0 +1,82 @@
+package commit
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/message"
+)
+
+const editMsgFile = "AI_COMMIT_EDITMSG"
+
+// Edit opens the message in the user's editor and returns it without comment lines
+func Edit(commitMsg string, absProjectDir string, files []changes.FileDiff) (string, error) {
+	msgPath, err := gitOutput(absProjectDir, "rev-parse", "--git-path", editMsgFile)
+	if err != nil {
+		return "", fmt.Errorf("error locating git directory: %v", err)
+	}
+	if !filepath.IsAbs(msgPath) {
+		msgPath = filepath.Join(absProjectDir, msgPath)
+	}
+
+	if err := os.WriteFile(msgPath, []byte(commitMsg+"\n"+editComments(files)), 0o644); err != nil {
+		return "", fmt.Errorf("error writing %s: %v", editMsgFile, err)
+	}
+
+	// git var resolves $GIT_EDITOR, core.editor, $VISUAL and $EDITOR in that order
+	editor, err := gitOutput(absProjectDir, "var", "GIT_EDITOR")
+	if err != nil {
+		return "", fmt.Errorf("error resolving editor: %v", err)
+	}
+
+	// Run through the shell like git does, so editors with arguments work
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, msgPath)
+	cmd.Dir = absProjectDir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %v", editor, err)
+	}
+
+	edited, err := os.ReadFile(msgPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", editMsgFile, err)
+	}
+
+	stripped := message.StripComments(string(edited))
+	if stripped == "" {
+		return "", fmt.Errorf("aborting commit due to empty commit message")
+	}
+	return stripped, nil
+}
+
+func editComments(files []changes.FileDiff) string {
+	var comments strings.Builder
+	comments.WriteString("\n# Please enter the commit message for your changes. Lines starting\n")
+	comments.WriteString("# with '#' will be ignored, and an empty message aborts the commit.\n")
+	comments.WriteString("#\n# Changes to be committed:\n")
+	for _, file := range files {
+		path := file.Path
+		if file.Status == changes.FileRenamed {
+			path = file.OldPath + " -> " + file.Path
+		}
+		comments.WriteString(fmt.Sprintf("#\t%-12s%s (+%d -%d)\n", file.Status.String()+":", path, file.Added, file.Deleted))
+	}
+	comments.WriteString("#\n")
+	return comments.String()
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/wert2all/ai-commit/changes"
+)
+
+// Exit codes are part of the command line interface, keep them stable.
+const (
+	exitOK         = 0
+	exitError      = 1
+	exitConfig     = 2
+	exitNoChanges  = 3
+	exitProvider   = 4
+	exitValidation = 5
+	exitCancelled  = 6
+)
+
+// codedError attaches an exit code to an error
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func (e *codedError) Unwrap() error { return e.err }
+
+func withExitCode(code int, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// exitCode maps an error to the process exit code
+func exitCode(err error) int {
+	var coded *codedError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.Is(err, changes.ErrNoChanges):
+		return exitNoChanges
+	default:
+		return exitError
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/message"
+	"github.com/wert2all/ai-commit/project"
+)
+
+// generation is the outcome of one round trip to the provider
+type generation struct {
+	providerInfo ai.ProviderInfo
+	context      *project.ProjectContext
+	candidates   []message.Message
+	latency      time.Duration
+	usage        ai.Usage
+	cost         float64
+	costKnown    bool
+	cached       bool
+	failures     []string
+}
+
+// generate builds the context and asks the configured provider for candidates
+func generate(config ai.Config, hint string) (*generation, error) {
+	provider, err := ai.NewProvider(config)
+	if err != nil {
+		return nil, withExitCode(exitProvider, err)
+	}
+
+	projectContext, err := buildContext(config, hint)
+	if err != nil {
+		return nil, err
+	}
+
+	generated, responses, err := roundTrip(config, provider, projectContext, config.Candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, response := range responses {
+		generated.candidates = append(generated.candidates, enforceScope(config, projectContext, message.Parse(response)))
+	}
+	return generated, nil
+}
+
+// structuredResponse drops the heuristic provider from the fallback chain of commands that
+// parse something else than a commit message from the response, and refuses it when selected
+func structuredResponse(config ai.Config) (ai.Config, error) {
+	if config.Type == ai.ProviderHeuristic {
+		return config, withExitCode(exitConfig, errors.New("heuristic provider only generates commit messages, choose another --provider"))
+	}
+	config.Fallback = slices.DeleteFunc(slices.Clone(config.Fallback), func(providerType ai.ProviderType) bool {
+		return providerType == ai.ProviderHeuristic
+	})
+	return config, nil
+}
+
+// enforceScope replaces the scope of the candidate with scopes.enforce when the changes
+// touch a single unit; for several units the prompt lists them and the model picks
+func enforceScope(config ai.Config, projectContext *project.ProjectContext, candidate message.Message) message.Message {
+	if !config.File.Scopes.Enforce || len(projectContext.Scopes) != 1 {
+		return candidate
+	}
+	return candidate.WithScope(projectContext.Scopes[0])
+}
+
+// roundTrip asks the provider and measures the request, returning the raw responses.
+// Commands expecting something else than commit messages parse the responses themselves.
+func roundTrip(config ai.Config, provider ai.Provider, projectContext *project.ProjectContext, candidates int) (*generation, []string, error) {
+	started := time.Now()
+	response, err := provider.GenerateCommitMessage(*projectContext, candidates)
+	latency := time.Since(started)
+	if err != nil {
+		return nil, nil, withExitCode(exitProvider, err)
+	}
+
+	providerInfo := provider.GetProviderInfo()
+	cost, costKnown := ai.EstimateCost(providerInfo.Type, providerInfo.Model, response.Usage, config.File.Prices)
+
+	return &generation{
+		providerInfo: providerInfo,
+		context:      projectContext,
+		candidates:   make([]message.Message, 0, len(response.Messages)),
+		latency:      latency,
+		usage:        response.Usage,
+		cost:         cost,
+		costKnown:    costKnown,
+		cached:       response.Cached,
+		failures:     response.Failures,
+	}, response.Messages, nil
+}
+
+// firstValid returns the first candidate following the convention,
+// or the first candidate and its violations when none does
+func firstValid(config ai.Config, candidates []message.Message) (message.Message, []message.Violation) {
+	var violations []message.Violation
+	for i, candidate := range candidates {
+		found := config.File.Convention.Validate(candidate.String())
+		if len(found) == 0 {
+			return candidate, nil
+		}
+		if i == 0 {
+			violations = found
+		}
+	}
+	return candidates[0], violations
+}
+
+// buildContext collects the project context according to the configured options
+func buildContext(config ai.Config, hint string) (*project.ProjectContext, error) {
+	contextBuilder, err := newContextBuilder(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if hint != "" {
+		contextBuilder.AddHint(hint)
+	}
+
+	return contextBuilder.Build()
+}
+
+// newContextBuilder prepares a builder with the context steps enabled by the options
+func newContextBuilder(config ai.Config) (project.ContextBuilder, error) {
+	contextBuilder, err := project.NewBuilder(config.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	contextBuilder.ExcludePaths(config.File.Policy.ExcludePaths)
+	contextBuilder.AddRedaction(config.File.Redaction)
+	contextBuilder.AddLanguages()
+	contextBuilder.AddGitBranch()
+	contextBuilder.AddChanges()
+	contextBuilder.AddSemanticChanges()
+	contextBuilder.AddScopes(config.File.Scopes)
+
+	if config.Options.WithChangedFilesContent {
+		contextBuilder.AddChangedFilesContent()
+	}
+
+	if config.Options.WithProjectConfig {
+		contextBuilder.AddProjectConfig()
+	}
+
+	if config.Options.WithBody {
+		contextBuilder.WithBody()
+	}
+
+	return contextBuilder, nil
+}
+
+// printFailures tells on stderr which providers of the fallback chain failed
+func printFailures(generated *generation) {
+	for _, failure := range generated.failures {
+		fmt.Fprintf(os.Stderr, "ai-commit: %s, falling back\n", failure)
+	}
+}
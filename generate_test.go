@@ -0,0 +1,22 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/wert2all/ai-commit/ai"
+)
+
+func TestStructuredResponseDropsHeuristicFallback(t *testing.T) {
+	fallback := []ai.ProviderType{ai.ProviderHeuristic, ai.ProviderLocal}
+	config, err := structuredResponse(ai.Config{Type: ai.ProviderClaude, Fallback: fallback})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []ai.ProviderType{ai.ProviderLocal}; !slices.Equal(config.Fallback, want) {
+		t.Errorf("Fallback = %v, want %v", config.Fallback, want)
+	}
+	if len(fallback) != 2 {
+		t.Errorf("the fallback of the original config was changed to %v", fallback)
+	}
+}
@@ -0,0 +1,164 @@
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/wert2all/ai-commit/changes"
+)
+
+// Commit is a commit to be rewritten, with what is needed to recreate it
+type Commit struct {
+	Hash    string   `json:"hash"`
+	Parents []string `json:"-"`
+	Tree    string   `json:"-"`
+	Message string   `json:"message"`
+	// author is kept as name, email and raw date
+	author [3]string
+}
+
+// Short returns the abbreviated hash
+func (c Commit) Short() string {
+	return c.Hash[:min(7, len(c.Hash))]
+}
+
+// List returns the commits reachable from head but not from base, oldest first.
+// An empty base lists the whole history of head. Merge commits cannot be rewritten
+// without replaying the merge, so a range containing one is refused.
+func List(dir, base, head string) ([]Commit, error) {
+	revisions := head
+	if base != "" {
+		revisions = base + ".." + head
+	}
+	output, err := git(dir, nil, "log", "--reverse", "--topo-order", "--date=raw",
+		"--format=%H%x00%P%x00%T%x00%an%x00%ae%x00%ad%x00%B%x1e", revisions)
+	if err != nil {
+		return nil, fmt.Errorf("error listing commits of %s: %v", revisions, err)
+	}
+	commits := make([]Commit, 0)
+	for record := range strings.SplitSeq(output, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 7)
+		if len(fields) < 7 {
+			continue
+		}
+		commit := Commit{
+			Hash:    fields[0],
+			Parents: strings.Fields(fields[1]),
+			Tree:    fields[2],
+			Message: strings.TrimSpace(fields[6]),
+			author:  [3]string{fields[3], fields[4], fields[5]},
+		}
+		if len(commit.Parents) > 1 {
+			return nil, fmt.Errorf("%s is a merge commit, merge commits cannot be reworded", commit.Short())
+		}
+		commits = append(commits, commit)
+	}
+	if len(commits) == 0 {
+		return nil, changes.ErrNoCommits
+	}
+	return commits, nil
+}
+
+// CheckProtected refuses commits that are already on a remote-tracking branch or on one
+// of the protected local branches, as rewriting them would diverge from what others have.
+// Every branch containing a later commit of the range also contains the oldest one.
+func CheckProtected(dir string, commits []Commit, protected []string) error {
+	oldest := commits[0]
+	patterns := []string{"refs/remotes/"}
+	for _, branch := range protected {
+		patterns = append(patterns, "refs/heads/"+branch)
+	}
+	args := append([]string{"for-each-ref", "--contains", oldest.Hash, "--format=%(refname:short)"}, patterns...)
+	output, err := git(dir, nil, args...)
+	if err != nil {
+		return fmt.Errorf("error checking the branches containing %s: %v", oldest.Short(), err)
+	}
+	refs := make([]string, 0)
+	for ref := range strings.SplitSeq(output, "\n") {
+		// origin/HEAD is an alias of the remote default branch
+		if ref != "" && !strings.HasSuffix(ref, "/HEAD") {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) > 0 {
+		return fmt.Errorf("%s is already on %s, rewriting it would diverge from that history; use --force to rewrite it anyway",
+			oldest.Short(), strings.Join(refs, ", "))
+	}
+	return nil
+}
+
+// Rewrite recreates the commits with the new messages on top of the parent of the oldest
+// one, keeping their trees and authors, and moves the current branch to the new tip.
+// The index and working tree are not touched since the trees do not change.
+// The range must end at HEAD and messages holds one message per commit.
+func Rewrite(dir string, commits []Commit, messages []string) (string, error) {
+	head, err := git(dir, nil, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("error reading HEAD: %v", err)
+	}
+	if head != commits[len(commits)-1].Hash {
+		return "", fmt.Errorf("the range must end at HEAD, check out the branch to reword first")
+	}
+	ref, err := git(dir, nil, "symbolic-ref", "-q", "HEAD")
+	if err != nil {
+		// detached HEAD
+		ref = "HEAD"
+	}
+
+	rewritten := make(map[string]string, len(commits))
+	tip := head
+	for i, commit := range commits {
+		args := []string{"commit-tree", commit.Tree}
+		for _, parent := range commit.Parents {
+			if replaced, ok := rewritten[parent]; ok {
+				parent = replaced
+			}
+			args = append(args, "-p", parent)
+		}
+		env := []string{
+			"GIT_AUTHOR_NAME=" + commit.author[0],
+			"GIT_AUTHOR_EMAIL=" + commit.author[1],
+			"GIT_AUTHOR_DATE=" + commit.author[2],
+		}
+		hash, err := gitEnv(dir, env, []byte(messages[i]+"\n"), args...)
+		if err != nil {
+			return "", fmt.Errorf("error recreating %s: %v", commit.Short(), err)
+		}
+		rewritten[commit.Hash] = hash
+		tip = hash
+	}
+
+	// the old value guards against the branch moving while the messages were generated
+	if _, err := git(dir, nil, "update-ref", "-m", "ai-commit: reword", ref, tip, head); err != nil {
+		return "", fmt.Errorf("error updating %s, the original commits are unchanged: %v", ref, err)
+	}
+	return tip, nil
+}
+
+func git(dir string, stdin []byte, args ...string) (string, error) {
+	return gitEnv(dir, nil, stdin, args...)
+}
+
+func gitEnv(dir string, env []string, stdin []byte, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if text := strings.TrimSpace(stderr.String()); text != "" {
+			return "", fmt.Errorf("%s", text)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
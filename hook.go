@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/hook"
+	"github.com/wert2all/ai-commit/ledger"
+	"github.com/wert2all/ai-commit/message"
+)
+
+const hookUsage = `usage:
+  ai-commit hook install|uninstall|status [prepare-commit-msg|commit-msg]
+  ai-commit hook run <msgfile> [<source> [<sha>]]
+  ai-commit hook commit-msg [--fix] <msgfile>`
+
+// hookCommands are the hook subcommands the installed scripts call
+var hookCommands = map[hook.Kind]string{
+	hook.PrepareCommitMsg: "run",
+	hook.CommitMsg:        "commit-msg",
+}
+
+func runHook(config ai.Config) {
+	if len(config.Args) < 2 {
+		handleError(errors.New(hookUsage))
+	}
+
+	switch config.Args[1] {
+	case "install", "uninstall", "status":
+		name := ""
+		if len(config.Args) > 2 {
+			name = config.Args[2]
+		}
+		kind, err := hook.ParseKind(name)
+		if err != nil {
+			handleError(err)
+		}
+		manageHook(config, config.Args[1], kind)
+	case hookCommands[hook.PrepareCommitMsg]:
+		if len(config.Args) < 3 {
+			handleError(errors.New(hookUsage))
+		}
+		runPrepareCommitMsg(config, config.Args[2:])
+	case hookCommands[hook.CommitMsg]:
+		if len(config.Args) < 3 {
+			handleError(errors.New(hookUsage))
+		}
+		runCommitMsg(config, config.Args[2])
+	default:
+		handleError(errors.New(hookUsage))
+	}
+}
+
+func manageHook(config ai.Config, action string, kind hook.Kind) {
+	var (
+		status hook.Status
+		err    error
+	)
+	switch action {
+	case "install":
+		executable, execErr := os.Executable()
+		if execErr != nil {
+			handleError(fmt.Errorf("error resolving ai-commit executable: %v", execErr))
+		}
+		command := append([]string{executable}, config.Flags...)
+		command = append(command, "hook", hookCommands[kind])
+		status, err = hook.Install(config.Directory, kind, command)
+	case "uninstall":
+		status, err = hook.Uninstall(config.Directory, kind)
+	default:
+		status, err = hook.GetStatus(config.Directory, kind)
+	}
+	if err != nil {
+		handleError(err)
+	}
+	printHookStatus(status)
+}
+
+// runPrepareCommitMsg fills in the message for plain "git commit".
+// Failures are reported but never block the commit.
+func runPrepareCommitMsg(config ai.Config, args []string) {
+	msgFile, source := args[0], ""
+	if len(args) > 1 {
+		source = args[1]
+	}
+	if !hook.ShouldPrepare(source) {
+		return
+	}
+
+	if err := prepareCommitMsg(config, msgFile); err != nil {
+		fmt.Fprintf(os.Stderr, "ai-commit: %v\n", err)
+	}
+}
+
+func prepareCommitMsg(config ai.Config, msgFile string) error {
+	config.Candidates = 1
+	generated, err := generate(config, "")
+	if err != nil {
+		return err
+	}
+	printFailures(generated)
+	commitMsg := generated.candidates[0].String()
+	recordRun(config, generated, ledger.OutcomeGenerated, commitMsg)
+	return hook.PrependMessage(msgFile, commitMsg)
+}
+
+// runCommitMsg rejects messages violating the convention, or rewrites them with --fix
+func runCommitMsg(config ai.Config, msgFile string) {
+	content, err := os.ReadFile(msgFile)
+	if err != nil {
+		rejectCommit(fmt.Errorf("error reading commit message file: %v", err), nil)
+	}
+	original := message.StripCommentsWith(string(content), hook.CommentString(config.Directory, string(content)))
+
+	violations := config.File.Convention.Validate(original)
+	if len(violations) == 0 {
+		return
+	}
+	if !config.Options.Fix {
+		rejectCommit(errors.New("commit message does not follow the commit convention"), violations)
+	}
+
+	rewritten, err := rewriteCommitMsg(config, original, violations)
+	if err != nil {
+		rejectCommit(fmt.Errorf("could not rewrite the commit message: %v", err), violations)
+	}
+	if remaining := config.File.Convention.Validate(rewritten); len(remaining) > 0 {
+		rejectCommit(errors.New("rewritten commit message still does not follow the commit convention"), remaining)
+	}
+
+	if err := os.WriteFile(msgFile, []byte(rewritten+"\n"), 0o644); err != nil {
+		rejectCommit(fmt.Errorf("error writing commit message file: %v", err), nil)
+	}
+	fmt.Fprintf(os.Stderr, "ai-commit: rewrote commit message to:\n\n%s\n\n", rewritten)
+}
+
+func rewriteCommitMsg(config ai.Config, original string, violations []message.Violation) (string, error) {
+	provider, err := ai.NewProvider(config)
+	if err != nil {
+		return "", err
+	}
+
+	problems := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		problems = append(problems, violation.String())
+	}
+
+	contextBuilder, err := newContextBuilder(config)
+	if err != nil {
+		return "", err
+	}
+	contextBuilder.AddRewrite(original, problems)
+	projectContext, err := contextBuilder.Build()
+	if err != nil {
+		return "", err
+	}
+
+	response, err := provider.GenerateCommitMessage(*projectContext, 1)
+	if err != nil {
+		return "", err
+	}
+	return message.Parse(response.Messages[0]).String(), nil
+}
+
+func rejectCommit(err error, violations []message.Violation) {
+	fmt.Fprintf(os.Stderr, "ai-commit: %v\n", err)
+	for _, violation := range violations {
+		fmt.Fprintf(os.Stderr, "  %s\n", violation)
+	}
+	os.Exit(exitValidation)
+}
+
+func printHookStatus(status hook.Status) {
+	fmt.Printf("Hook:      %s\n", status.Kind)
+	fmt.Printf("Path:      %s\n", status.Path)
+	switch {
+	case status.Installed:
+		fmt.Println("Installed: yes")
+	case status.Foreign:
+		fmt.Println("Installed: no (another hook exists)")
+	default:
+		fmt.Println("Installed: no")
+	}
+	if status.Chained != "" {
+		fmt.Printf("Chained:   %s\n", status.Chained)
+	}
+}
@@ -0,0 +1,159 @@
+package hook
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// marker identifies hook scripts written by ai-commit
+const marker = "# installed by ai-commit"
+
+// chainedSuffix is appended to a pre-existing hook that ai-commit calls before itself
+const chainedSuffix = ".pre-ai-commit"
+
+type (
+	Kind   string
+	Status struct {
+		Kind      Kind
+		Path      string
+		Installed bool
+		// Foreign is set when a hook not managed by ai-commit occupies the path
+		Foreign bool
+		// Chained is the path of the previous hook called before ai-commit, if any
+		Chained string
+	}
+)
+
+const (
+	PrepareCommitMsg Kind = "prepare-commit-msg"
+	CommitMsg        Kind = "commit-msg"
+)
+
+// ParseKind accepts the supported hook names, defaulting to prepare-commit-msg
+func ParseKind(name string) (Kind, error) {
+	switch Kind(name) {
+	case "", PrepareCommitMsg:
+		return PrepareCommitMsg, nil
+	case CommitMsg:
+		return CommitMsg, nil
+	default:
+		return "", fmt.Errorf("unsupported hook: %s", name)
+	}
+}
+
+// Dir returns the hooks directory of the repository, respecting core.hooksPath
+func Dir(absProjectDir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = absProjectDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error locating git hooks directory: %v", err)
+	}
+	hooksDir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(absProjectDir, hooksDir)
+	}
+	return hooksDir, nil
+}
+
+// Install writes a hook executing command with the hook arguments.
+// An existing hook not managed by ai-commit is moved aside and called first.
+func Install(absProjectDir string, kind Kind, command []string) (Status, error) {
+	status, err := GetStatus(absProjectDir, kind)
+	if err != nil {
+		return status, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(status.Path), 0o755); err != nil {
+		return status, fmt.Errorf("error creating hooks directory: %v", err)
+	}
+
+	if status.Foreign {
+		if status.Chained != "" {
+			return status, fmt.Errorf("both %s and %s exist, refusing to overwrite", status.Path, status.Chained)
+		}
+		if err := os.Rename(status.Path, status.Path+chainedSuffix); err != nil {
+			return status, fmt.Errorf("error moving existing hook aside: %v", err)
+		}
+		status.Chained = status.Path + chainedSuffix
+	}
+
+	if err := os.WriteFile(status.Path, []byte(script(command)), 0o755); err != nil {
+		return status, fmt.Errorf("error writing hook: %v", err)
+	}
+	status.Installed = true
+	status.Foreign = false
+	return status, nil
+}
+
+// Uninstall removes the ai-commit hook and restores a chained hook if there was one
+func Uninstall(absProjectDir string, kind Kind) (Status, error) {
+	status, err := GetStatus(absProjectDir, kind)
+	if err != nil {
+		return status, err
+	}
+	if !status.Installed {
+		return status, fmt.Errorf("%s hook is not installed by ai-commit", kind)
+	}
+
+	if err := os.Remove(status.Path); err != nil {
+		return status, fmt.Errorf("error removing hook: %v", err)
+	}
+	status.Installed = false
+
+	if status.Chained != "" {
+		if err := os.Rename(status.Chained, status.Path); err != nil {
+			return status, fmt.Errorf("error restoring previous hook: %v", err)
+		}
+		status.Chained = ""
+		status.Foreign = true
+	}
+	return status, nil
+}
+
+func GetStatus(absProjectDir string, kind Kind) (Status, error) {
+	hooksDir, err := Dir(absProjectDir)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{Kind: kind, Path: filepath.Join(hooksDir, string(kind))}
+	content, err := os.ReadFile(status.Path)
+	switch {
+	case err == nil:
+		status.Installed = bytes.Contains(content, []byte(marker))
+		status.Foreign = !status.Installed
+	case !os.IsNotExist(err):
+		return status, fmt.Errorf("error reading hook: %v", err)
+	}
+
+	if _, err := os.Stat(status.Path + chainedSuffix); err == nil {
+		status.Chained = status.Path + chainedSuffix
+	}
+	return status, nil
+}
+
+func script(command []string) string {
+	quoted := make([]string, 0, len(command))
+	for _, arg := range command {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	var hook strings.Builder
+	hook.WriteString("#!/bin/sh\n")
+	hook.WriteString(marker + "\n\n")
+	hook.WriteString(fmt.Sprintf("chained=\"$0%s\"\n", chainedSuffix))
+	hook.WriteString("if [ -x \"$chained\" ]; then\n")
+	hook.WriteString("\t\"$chained\" \"$@\" || exit $?\n")
+	hook.WriteString("fi\n\n")
+	hook.WriteString(fmt.Sprintf("exec %s \"$@\"\n", strings.Join(quoted, " ")))
+	return hook.String()
+}
+
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
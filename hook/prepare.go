@@ -0,0 +1,59 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// autoCommentChars are the characters git picks from, in order, when core.commentChar is auto
+const autoCommentChars = "#;@!$%^&|:"
+
+// ShouldPrepare reports whether prepare-commit-msg should fill in a message.
+// Any source (message, template, merge, squash or commit) means git or the user already provided one.
+func ShouldPrepare(source string) bool {
+	return source == ""
+}
+
+// PrependMessage writes msg above the content git already put into the message file
+func PrependMessage(msgFile string, msg string) error {
+	existing, err := os.ReadFile(msgFile)
+	if err != nil {
+		return fmt.Errorf("error reading commit message file: %v", err)
+	}
+	if err := os.WriteFile(msgFile, append([]byte(msg+"\n"), existing...), 0o644); err != nil {
+		return fmt.Errorf("error writing commit message file: %v", err)
+	}
+	return nil
+}
+
+// CommentString returns the prefix of comment lines in a message file, as set by
+// core.commentString or core.commentChar and defaulting to "#"
+func CommentString(absProjectDir string, content string) string {
+	for _, key := range []string{"core.commentString", "core.commentChar"} {
+		cmd := exec.Command("git", "config", "--get", key)
+		cmd.Dir = absProjectDir
+		if output, err := cmd.Output(); err == nil {
+			return commentFor(strings.TrimRight(string(output), "\n"), content)
+		}
+	}
+	return "#"
+}
+
+// commentFor resolves the configured comment string. With auto, git uses the first
+// character not starting a line of the message for the comments below it, so the
+// comment character is the one starting the last line.
+func commentFor(configured, content string) string {
+	if configured != "auto" {
+		if configured == "" {
+			return "#"
+		}
+		return configured
+	}
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if last := lines[len(lines)-1]; last != "" && strings.ContainsRune(autoCommentChars, rune(last[0])) {
+		return last[:1]
+	}
+	return "#"
+}
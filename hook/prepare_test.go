@@ -0,0 +1,22 @@
+package hook
+
+import "testing"
+
+func TestCommentFor(t *testing.T) {
+	tests := []struct {
+		name, configured, content, want string
+	}{
+		{"unset", "", "fix: x\n# comment\n", "#"},
+		{"char", ";", "fix: x\n; comment\n", ";"},
+		{"string", "//", "fix: x\n// comment\n", "//"},
+		{"auto", "auto", "fix: x\n\n#1 issue\n; Please enter the commit message\n;\n", ";"},
+		{"auto without comments", "auto", "fix: x\n", "#"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := commentFor(test.configured, test.content); got != test.want {
+				t.Errorf("commentFor(%q) = %q, want %q", test.configured, got, test.want)
+			}
+		})
+	}
+}
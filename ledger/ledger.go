@@ -0,0 +1,218 @@
+package ledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const fileName = "ledger.jsonl"
+
+type (
+	Outcome string
+	// Entry is one generation, stored as a JSON line
+	Entry struct {
+		Time             time.Time `json:"time"`
+		Repo             string    `json:"repo"`
+		Provider         string    `json:"provider"`
+		Model            string    `json:"model"`
+		PromptTokens     int       `json:"prompt_tokens"`
+		CompletionTokens int       `json:"completion_tokens"`
+		CostUSD          *float64  `json:"cost_usd"`
+		LatencyMS        int64     `json:"latency_ms"`
+		Outcome          Outcome   `json:"outcome"`
+		Message          string    `json:"message"`
+	}
+	Filter struct {
+		Since time.Time
+		Until time.Time
+	}
+	Group struct {
+		Key              string  `json:"key"`
+		Runs             int     `json:"runs"`
+		Accepted         int     `json:"accepted"`
+		Edited           int     `json:"edited"`
+		Rejected         int     `json:"rejected"`
+		Generated        int     `json:"generated"`
+		PromptTokens     int     `json:"prompt_tokens"`
+		CompletionTokens int     `json:"completion_tokens"`
+		CostUSD          float64 `json:"cost_usd"`
+		// UnknownCost counts runs whose model price was not known
+		UnknownCost    int   `json:"unknown_cost"`
+		TotalLatencyMS int64 `json:"-"`
+	}
+)
+
+const (
+	OutcomeAccepted Outcome = "accepted"
+	OutcomeEdited   Outcome = "edited"
+	OutcomeRejected Outcome = "rejected"
+	// OutcomeGenerated is used when no decision was asked for, e.g. --print-only or hooks
+	OutcomeGenerated Outcome = "generated"
+)
+
+const (
+	GroupByProvider = "provider"
+	GroupByModel    = "model"
+	GroupByRepo     = "repo"
+)
+
+// Path returns the ledger location under $XDG_STATE_HOME/ai-commit
+func Path() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error resolving home directory: %v", err)
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "ai-commit", fileName), nil
+}
+
+func Append(entry Entry) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("error creating ledger directory: %v", err)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding ledger entry: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening ledger: %v", err)
+	}
+	// nolint
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing ledger: %v", err)
+	}
+	return nil
+}
+
+// Read returns the entries matching the filter, skipping lines it cannot parse
+func Read(filter Filter) ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening ledger: %v", err)
+	}
+	// nolint
+	defer file.Close()
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if !filter.Since.IsZero() && entry.Time.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !entry.Time.Before(filter.Until) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading ledger: %v", err)
+	}
+	return entries, nil
+}
+
+// Aggregate groups entries by provider, model or repo, sorted by number of runs
+func Aggregate(entries []Entry, groupBy string) ([]Group, error) {
+	if groupBy != GroupByProvider && groupBy != GroupByModel && groupBy != GroupByRepo {
+		return nil, fmt.Errorf("unknown grouping: %s, expected provider, model or repo", groupBy)
+	}
+
+	groups := make(map[string]*Group)
+	for _, entry := range entries {
+		var key string
+		switch groupBy {
+		case GroupByProvider:
+			key = entry.Provider
+		case GroupByModel:
+			key = entry.Provider + "/" + entry.Model
+		case GroupByRepo:
+			key = entry.Repo
+		}
+
+		group, ok := groups[key]
+		if !ok {
+			group = &Group{Key: key}
+			groups[key] = group
+		}
+		group.add(entry)
+	}
+
+	result := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Runs != result[j].Runs {
+			return result[i].Runs > result[j].Runs
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+func (g *Group) add(entry Entry) {
+	g.Runs++
+	switch entry.Outcome {
+	case OutcomeAccepted:
+		g.Accepted++
+	case OutcomeEdited:
+		g.Edited++
+	case OutcomeRejected:
+		g.Rejected++
+	default:
+		g.Generated++
+	}
+	g.PromptTokens += entry.PromptTokens
+	g.CompletionTokens += entry.CompletionTokens
+	if entry.CostUSD != nil {
+		g.CostUSD += *entry.CostUSD
+	} else {
+		g.UnknownCost++
+	}
+	g.TotalLatencyMS += entry.LatencyMS
+}
+
+// AcceptanceRate is the share of decided runs that were committed, edited or not
+func (g Group) AcceptanceRate() float64 {
+	decided := g.Accepted + g.Edited + g.Rejected
+	if decided == 0 {
+		return 0
+	}
+	return float64(g.Accepted+g.Edited) / float64(decided)
+}
+
+func (g Group) AverageLatency() time.Duration {
+	if g.Runs == 0 {
+		return 0
+	}
+	return time.Duration(g.TotalLatencyMS/int64(g.Runs)) * time.Millisecond
+}
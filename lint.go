@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/ledger"
+	"github.com/wert2all/ai-commit/message"
+	"github.com/wert2all/ai-commit/project"
+)
+
+const (
+	lintUsage = "usage: ai-commit lint [<base>[..<head>]] [--score] [--min-score <1-5>] [--output json]"
+
+	maxScore = 5
+)
+
+type (
+	jsonLintReport struct {
+		Base    string             `json:"base"`
+		Head    string             `json:"head"`
+		Valid   bool               `json:"valid"`
+		Commits []jsonLintedCommit `json:"commits"`
+		// Provider is set when the subjects were scored
+		Provider *ai.ProviderInfo `json:"provider,omitempty"`
+	}
+	jsonLintedCommit struct {
+		Hash       string              `json:"hash"`
+		Subject    string              `json:"subject"`
+		Valid      bool                `json:"valid"`
+		Violations []message.Violation `json:"violations"`
+		Score      *int                `json:"score,omitempty"`
+		Reason     string              `json:"reason,omitempty"`
+	}
+	// subjectScore is the rating returned by the provider
+	subjectScore struct {
+		Score  int    `json:"score"`
+		Reason string `json:"reason"`
+	}
+)
+
+// runLint validates the messages of a range against the convention and optionally lets
+// the provider score their subjects, failing with exitValidation for CI
+func runLint(config ai.Config) error {
+	options := config.Options
+	if len(config.Args) > 2 || options.MinScore < 0 || options.MinScore > maxScore {
+		return errors.New(lintUsage)
+	}
+	if options.Score {
+		var err error
+		if config, err = structuredResponse(config); err != nil {
+			return err
+		}
+	}
+	base, head, err := revisionRange(config)
+	if err != nil {
+		return err
+	}
+	commits, err := changes.Commits(config.Directory, base, head)
+	if err != nil {
+		return withExitCode(exitNoChanges, err)
+	}
+
+	var provider ai.Provider
+	if options.Score {
+		if provider, err = ai.NewProvider(config); err != nil {
+			return withExitCode(exitProvider, err)
+		}
+	}
+
+	report := jsonLintReport{Base: base, Head: head, Valid: true, Commits: make([]jsonLintedCommit, 0, len(commits))}
+	failed := 0
+	for _, commit := range commits {
+		violations := config.File.Convention.Validate(commit.Message)
+		linted := jsonLintedCommit{Hash: commit.Hash, Subject: commit.Subject, Valid: len(violations) == 0, Violations: violations}
+		if provider != nil {
+			generated, score, err := scoreSubject(config, provider, commit)
+			if err != nil {
+				return fmt.Errorf("%s: %w", commit.Hash[:7], err)
+			}
+			if score != nil {
+				linted.Score = &score.Score
+				linted.Reason = score.Reason
+				report.Provider = &generated.providerInfo
+				if score.Score < options.MinScore {
+					linted.Valid = false
+				}
+			}
+		}
+		if !linted.Valid {
+			failed++
+			report.Valid = false
+		}
+		report.Commits = append(report.Commits, linted)
+	}
+
+	if jsonOutput {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+		if !report.Valid {
+			os.Exit(exitValidation)
+		}
+		return nil
+	}
+
+	if err := printLintTable(report.Commits, options.Score); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return withExitCode(exitValidation, fmt.Errorf("%d of %d commits do not pass the lint", failed, len(commits)))
+	}
+	fmt.Printf("\nAll %d commits pass the lint.\n", len(commits))
+	return nil
+}
+
+// scoreSubject asks the provider how well the subject describes the changes of the commit.
+// Commits without changes are not scored.
+func scoreSubject(config ai.Config, provider ai.Provider, commit changes.Commit) (*generation, *subjectScore, error) {
+	commitChanges, err := changes.NewCommitChanges(config.Directory, commit.Hash)
+	if errors.Is(err, changes.ErrNoChanges) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contextBuilder, err := project.NewBuilder(config.Directory)
+	if err != nil {
+		return nil, nil, err
+	}
+	contextBuilder.ExcludePaths(config.File.Policy.ExcludePaths)
+	contextBuilder.AddRedaction(config.File.Redaction)
+	contextBuilder.AddScore(commitChanges, commit.Subject)
+	projectContext, err := contextBuilder.Build()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	generated, responses, err := roundTrip(config, provider, projectContext, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	recordRun(config, generated, ledger.OutcomeGenerated, commit.Subject)
+	if !jsonOutput {
+		printFailures(generated)
+	}
+
+	raw := responses[0]
+	start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}")
+	if start < 0 || end < start {
+		return nil, nil, withExitCode(exitProvider, errors.New("provider did not return a score"))
+	}
+	var score subjectScore
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &score); err != nil {
+		return nil, nil, withExitCode(exitProvider, fmt.Errorf("error parsing score: %v", err))
+	}
+	score.Score = max(1, min(maxScore, score.Score))
+	return generated, &score, nil
+}
+
+// printLintTable lists every commit with its score, followed by its violations and the reason of the score
+func printLintTable(commits []jsonLintedCommit, withScore bool) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if withScore {
+		fmt.Fprintln(writer, "Commit\tScore\tSubject")
+	} else {
+		fmt.Fprintln(writer, "Commit\tSubject")
+	}
+	for _, commit := range commits {
+		hash := commit.Hash[:7]
+		if withScore {
+			score := "-"
+			if commit.Score != nil {
+				score = fmt.Sprintf("%d/%d", *commit.Score, maxScore)
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", hash, score, commit.Subject)
+		} else {
+			fmt.Fprintf(writer, "%s\t%s\n", hash, commit.Subject)
+		}
+
+		details := make([]string, 0, len(commit.Violations)+1)
+		for _, violation := range commit.Violations {
+			details = append(details, "! "+violation.String())
+		}
+		if commit.Reason != "" {
+			details = append(details, "  "+commit.Reason)
+		}
+		for _, detail := range details {
+			if withScore {
+				fmt.Fprintf(writer, "\t\t%s\n", detail)
+			} else {
+				fmt.Fprintf(writer, "\t%s\n", detail)
+			}
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+func TestLintScoreRefusesHeuristicProvider(t *testing.T) {
+	config := heuristicConfig(t, "lint", "main..HEAD")
+	config.Options.Score = true
+	assertHeuristicRefused(t, runLint(config))
+
+	// without --score the provider is not asked
+	config.Options.Score = false
+	if err := runLint(config); err == nil || exitCode(err) == exitConfig {
+		t.Errorf("lint without --score failed with %v, want a git error", err)
+	}
+}
@@ -1,73 +1,276 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 
 	"github.com/wert2all/ai-commit/ai"
 	"github.com/wert2all/ai-commit/commit"
-	"github.com/wert2all/ai-commit/project"
+	"github.com/wert2all/ai-commit/ledger"
+	"github.com/wert2all/ai-commit/message"
+	"github.com/wert2all/ai-commit/tui"
 	"github.com/wert2all/ai-commit/ui"
 )
 
 var (
 	cardWidth = 60
 	Version   = "dev"
+
+	errCancelled = errors.New("commit cancelled")
 )
 
 func main() {
-	config, err := ai.ReadConfig()
-	if err != nil {
-		handleError(err)
+	config, err := ai.ReadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(exitOK)
+	}
+	var configErr *ai.ConfigError
+	if errors.As(err, &configErr) {
+		jsonOutput = configErr.Output == ai.OutputJSON
+		handleError(withExitCode(exitConfig, err))
 	}
+	jsonOutput = config.Options.Output == ai.OutputJSON
 
 	if config.Options.ShowVersion {
 		fmt.Printf("Version: %s\n", Version)
 		os.Exit(0)
 	}
 
-	provider, err := ai.NewProvider(*config)
+	if len(config.Args) > 0 {
+		switch config.Args[0] {
+		case "hook":
+			runHook(*config)
+		case "stats":
+			err = runStats(*config)
+		case "cache":
+			err = runCache(*config)
+		case "split":
+			err = runSplit(*config)
+		case "pr":
+			err = runPR(*config)
+		case "changelog":
+			err = runChangelog(*config)
+		case "reword":
+			err = runReword(*config)
+		case "lint":
+			err = runLint(*config)
+		case "branch":
+			err = runBranch(*config)
+		default:
+			err = fmt.Errorf("unknown command: %s", config.Args[0])
+		}
+		if err != nil {
+			handleError(err)
+		}
+		return
+	}
+
+	switch {
+	case jsonOutput:
+		err = runJSON(*config)
+	case config.Options.PrintOnly:
+		err = runPrintOnly(*config)
+	case config.Options.WithCommit && !config.Options.Yes && !config.Options.Plain && ui.IsTerminal():
+		err = runInteractive(*config)
+	default:
+		err = runPlain(*config)
+	}
+	if errors.Is(err, errCancelled) {
+		fmt.Println("Commit cancelled.")
+		os.Exit(exitCancelled)
+	}
 	if err != nil {
 		handleError(err)
 	}
+}
 
-	contextBuilder, err := project.NewBuilder(config.Directory)
+// runPrintOnly writes just the raw message to stdout for use in pipelines
+func runPrintOnly(config ai.Config) error {
+	generated, err := generate(config, "")
 	if err != nil {
-		handleError(err)
+		return err
 	}
 
-	contextBuilder.AddLanguages()
-	contextBuilder.AddGitBranch()
-	contextBuilder.AddChanges()
+	printFailures(generated)
+	if len(generated.context.Redactions) > 0 {
+		fmt.Fprintln(os.Stderr, ui.NewRedactionInfo(generated.context.Redactions))
+	}
 
-	if config.Options.WithChangedFilesContent {
-		contextBuilder.AddChangedFilesContent()
+	commitMsg, violations := firstValid(config, generated.candidates)
+	recordRun(config, generated, ledger.OutcomeGenerated, commitMsg.String())
+	if len(violations) > 0 {
+		return validationError(violations)
 	}
+	fmt.Println(commitMsg.String())
+	return nil
+}
 
-	projectContext, err := contextBuilder.Build()
-	if err != nil {
-		handleError(err)
+// runPlain prints the candidates as cards and asks on stdin when it is a terminal
+func runPlain(config ai.Config) error {
+	for {
+		generated, err := generate(config, "")
+		if err != nil {
+			return err
+		}
+
+		printFailures(generated)
+		fmt.Println(ui.NewProviderInfo(generated.providerInfo))
+		fmt.Println(ui.NewUsageInfo(generated.usage, generated.cost, generated.costKnown, generated.cached))
+		if len(generated.context.Redactions) > 0 {
+			fmt.Println(ui.NewRedactionInfo(generated.context.Redactions))
+		}
+		printCandidates(config, generated.candidates)
+
+		firstMsg := generated.candidates[0].String()
+		if !config.Options.WithCommit {
+			recordRun(config, generated, ledger.OutcomeGenerated, firstMsg)
+			return nil
+		}
+
+		if config.Options.Yes {
+			commitMsg, violations := firstValid(config, generated.candidates)
+			if len(violations) > 0 {
+				recordRun(config, generated, ledger.OutcomeRejected, commitMsg.String())
+				return validationError(violations)
+			}
+			return commitMessage(config, generated, ledger.OutcomeAccepted, commitMsg.String())
+		}
+
+		if !ui.IsInputTerminal() {
+			recordRun(config, generated, ledger.OutcomeGenerated, firstMsg)
+			fmt.Fprintln(os.Stderr, "stdin is not a terminal, not committing; use --yes to commit without confirmation")
+			return nil
+		}
+
+		answer := commit.AskUser(len(generated.candidates))
+		switch answer.Action {
+		case commit.ActionRegenerate:
+			recordRun(config, generated, ledger.OutcomeRejected, firstMsg)
+			// the cached response is what was just rejected
+			config.Options.NoCache = true
+			continue
+		case commit.ActionCancel:
+			recordRun(config, generated, ledger.OutcomeRejected, firstMsg)
+			return errCancelled
+		}
+
+		commitMsg := generated.candidates[answer.Choice].String()
+		if answer.Action == commit.ActionEdit {
+			edited, err := commit.Edit(commitMsg, config.Directory, generated.context.Changes.Files())
+			if err != nil {
+				recordRun(config, generated, ledger.OutcomeRejected, commitMsg)
+				return withExitCode(exitCancelled, err)
+			}
+			commitMsg = edited
+		}
+
+		return commitMessage(config, generated, commitOutcome(generated, commitMsg), commitMsg)
 	}
+}
 
-	commitMsg, err := provider.GenerateCommitMessage(*projectContext)
+// runInteractive lets the user review, tweak and regenerate the message in the terminal UI
+func runInteractive(config ai.Config) error {
+	settings := tui.Settings{
+		Provider:         config.Type,
+		Providers:        config.File.Policy.Providers(),
+		Model:            config.Model,
+		WithFilesContent: config.Options.WithChangedFilesContent,
+	}
+
+	// every generation but the last one was rejected by asking for another
+	var last *generation
+	generateFunc := func(settings tui.Settings) (*tui.Generation, error) {
+		current := config
+		if settings.Provider != config.Type || settings.Model != config.Model {
+			current = config.WithProvider(settings.Provider, settings.Model)
+		}
+		current.Options.WithChangedFilesContent = settings.WithFilesContent
+		// only the first generation may come from the cache, later ones are regenerations
+		current.Options.NoCache = current.Options.NoCache || last != nil
+
+		generated, err := generate(current, settings.Hint)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			recordRun(config, last, ledger.OutcomeRejected, last.candidates[0].String())
+		}
+		last = generated
+		return &tui.Generation{
+			ProviderInfo: generated.providerInfo,
+			Files:        generated.context.Changes.Files(),
+			Messages:     generated.candidates,
+			Usage:        generated.usage,
+			Cost:         generated.cost,
+			CostKnown:    generated.costKnown,
+			Cached:       generated.cached,
+			Redactions:   generated.context.Redactions,
+		}, nil
+	}
+
+	result, err := tui.Run(settings, generateFunc, cardWidth)
 	if err != nil {
-		handleError(err)
+		return err
+	}
+	if !result.Accepted {
+		if last != nil {
+			recordRun(config, last, ledger.OutcomeRejected, last.candidates[0].String())
+		}
+		return errCancelled
 	}
 
-	fmt.Println(ui.NewProviderInfo(provider.GetProviderInfo()))
-	fmt.Println(ui.NewCard("Commit message", commitMsg, cardWidth))
+	commitMsg := result.Message.String()
+	if result.OpenEditor {
+		edited, err := commit.Edit(commitMsg, config.Directory, result.Files)
+		if err != nil {
+			recordRun(config, last, ledger.OutcomeRejected, commitMsg)
+			return withExitCode(exitCancelled, err)
+		}
+		commitMsg = edited
+	} else {
+		fmt.Println(ui.NewMessageCard("Commit message", result.Message, cardWidth))
+	}
 
-	if config.Options.WithCommit {
-		if shouldCommit := commit.AskUser(); shouldCommit {
-			commit.Commit(commitMsg, config.Directory)
-			fmt.Println("Successfully committed changes with the generated message!")
-		} else {
-			fmt.Println("Commit cancelled.")
+	return commitMessage(config, last, commitOutcome(last, commitMsg), commitMsg)
+}
+
+// commitMessage commits and records the run, as rejected when the commit fails
+func commitMessage(config ai.Config, generated *generation, outcome ledger.Outcome, commitMsg string) error {
+	if err := commit.Commit(commitMsg, config.Directory); err != nil {
+		recordRun(config, generated, ledger.OutcomeRejected, commitMsg)
+		return err
+	}
+	recordRun(config, generated, outcome, commitMsg)
+	fmt.Println("Successfully committed changes with the generated message!")
+	return nil
+}
+
+// printCandidates shows the messages as numbered cards with their convention violations
+func printCandidates(config ai.Config, candidates []message.Message) {
+	for i, candidate := range candidates {
+		title := "Commit message"
+		if len(candidates) > 1 {
+			title = fmt.Sprintf("Commit message #%d", i+1)
+		}
+		fmt.Println(ui.NewMessageCard(title, candidate, cardWidth))
+		if violations := config.File.Convention.Validate(candidate.String()); len(violations) > 0 {
+			fmt.Println(ui.NewViolations(violations))
 		}
 	}
 }
 
+func validationError(violations []message.Violation) error {
+	err := fmt.Errorf("generated message does not follow the commit convention: %s", violations[0])
+	return withExitCode(exitValidation, err)
+}
+
 func handleError(err error) {
-	fmt.Println(ui.NewError(err.Error(), cardWidth))
-	os.Exit(1)
+	if jsonOutput {
+		printJSONError(err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Fprintln(os.Stderr, ui.NewError(err.Error(), cardWidth))
+	os.Exit(exitCode(err))
 }
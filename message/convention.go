@@ -0,0 +1,150 @@
+package message
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var (
+	headerPattern = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?: (.*)$`)
+	scopePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9._/-]*$`)
+	// exemptPattern matches messages generated by git itself or meant for autosquash
+	exemptPattern = regexp.MustCompile(`^(Merge |Revert "|fixup! |squash! |amend! )`)
+)
+
+// scissors follows the comment character on the line git cuts the message at
+const scissors = " ------------------------ >8 ------------------------"
+
+type (
+	// Convention describes the rules commit messages are validated against
+	Convention struct {
+		Types             []string `json:"types"`
+		Scopes            []string `json:"scopes"`
+		RequireScope      bool     `json:"require_scope"`
+		MaxHeaderLength   int      `json:"max_header_length"`
+		MaxBodyLineLength int      `json:"max_body_line_length"`
+	}
+	Violation struct {
+		// Line and Column are 1-based positions in the message
+		Line    int    `json:"line"`
+		Column  int    `json:"column"`
+		Rule    string `json:"rule"`
+		Message string `json:"message"`
+	}
+)
+
+// DefaultConvention returns the Conventional Commits rules used by the system prompt
+func DefaultConvention() Convention {
+	return Convention{
+		Types:             []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"},
+		MaxHeaderLength:   72,
+		MaxBodyLineLength: 100,
+	}
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%d:%d %s: %s", v.Line, v.Column, v.Rule, v.Message)
+}
+
+// StripComments removes git comment lines and everything below the scissors line
+func StripComments(raw string) string {
+	return StripCommentsWith(raw, "#")
+}
+
+// StripCommentsWith is StripComments for comment lines starting with comment, see core.commentChar
+func StripCommentsWith(raw, comment string) string {
+	lines := make([]string, 0)
+	for line := range strings.SplitSeq(strings.ReplaceAll(raw, "\r\n", "\n"), "\n") {
+		if line == comment+scissors {
+			break
+		}
+		if strings.HasPrefix(line, comment) {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Validate checks a raw commit message against the convention
+func (c Convention) Validate(raw string) []Violation {
+	lines := strings.Split(StripComments(raw), "\n")
+	header := lines[0]
+	violations := make([]Violation, 0)
+	add := func(line, column int, rule, format string, args ...any) {
+		violations = append(violations, Violation{Line: line, Column: column, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(header) == "" {
+		add(1, 1, "header-empty", "commit message is empty")
+		return violations
+	}
+	if exemptPattern.MatchString(header) {
+		return violations
+	}
+
+	if c.MaxHeaderLength > 0 && len(header) > c.MaxHeaderLength {
+		add(1, c.MaxHeaderLength+1, "header-max-length", "header is %d characters long, maximum is %d", len(header), c.MaxHeaderLength)
+	}
+
+	match := headerPattern.FindStringSubmatchIndex(header)
+	if match == nil {
+		add(1, 1, "header-format", "header must look like \"<type>[optional scope]: <description>\"")
+	} else {
+		c.validateHeader(header, match, add)
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		add(2, 1, "body-leading-blank", "body must be separated from the header by a blank line")
+	}
+	if c.MaxBodyLineLength > 0 {
+		for i, line := range lines[1:] {
+			if len(line) > c.MaxBodyLineLength && !strings.Contains(line, "://") {
+				add(i+2, c.MaxBodyLineLength+1, "body-max-line-length", "line is %d characters long, maximum is %d", len(line), c.MaxBodyLineLength)
+			}
+		}
+	}
+
+	return violations
+}
+
+func (c Convention) validateHeader(header string, match []int, add func(line, column int, rule, format string, args ...any)) {
+	commitType := header[match[2]:match[3]]
+	switch {
+	case commitType != strings.ToLower(commitType):
+		add(1, match[2]+1, "type-case", "type %q must be lower case", commitType)
+	case len(c.Types) > 0 && !slices.Contains(c.Types, commitType):
+		add(1, match[2]+1, "type-enum", "type %q must be one of: %s", commitType, strings.Join(c.Types, ", "))
+	}
+
+	if match[4] < 0 {
+		if c.RequireScope {
+			add(1, match[3]+1, "scope-empty", "scope is required")
+		}
+	} else {
+		scope := header[match[4]:match[5]]
+		for part := range strings.SplitSeq(scope, ",") {
+			part = strings.TrimSpace(part)
+			switch {
+			case !scopePattern.MatchString(part):
+				add(1, match[4]+1, "scope-case", "scope %q must be lower case without spaces", part)
+			case len(c.Scopes) > 0 && !slices.Contains(c.Scopes, part):
+				add(1, match[4]+1, "scope-enum", "scope %q must be one of: %s", part, strings.Join(c.Scopes, ", "))
+			}
+		}
+	}
+
+	description := header[match[8]:match[9]]
+	column := match[8] + 1
+	switch {
+	case strings.TrimSpace(description) == "":
+		add(1, column, "subject-empty", "description must not be empty")
+	case unicode.IsUpper([]rune(description)[0]):
+		add(1, column, "subject-case", "description must not start with a capital letter")
+	case strings.HasSuffix(description, "."):
+		add(1, len(header), "subject-full-stop", "description must not end with a period")
+	}
+}
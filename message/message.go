@@ -0,0 +1,235 @@
+package message
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BodyWidth is the column at which body paragraphs are wrapped
+const BodyWidth = 72
+
+var (
+	footerPattern = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[A-Za-z][\w-]*)(: | #)(.*)$`)
+	fencePattern  = regexp.MustCompile("^```[\\w-]*$")
+)
+
+type (
+	Footer struct {
+		Token string `json:"token"`
+		Value string `json:"value"`
+		// Separator is either ": " or " #" as in "Refs #123"
+		Separator string `json:"-"`
+	}
+	Message struct {
+		Subject string   `json:"subject"`
+		Body    string   `json:"body"`
+		Footers []Footer `json:"footers"`
+	}
+	// Header is a subject split into its Conventional Commits parts
+	Header struct {
+		Type        string `json:"type"`
+		Scope       string `json:"scope"`
+		Breaking    bool   `json:"breaking"`
+		Description string `json:"description"`
+	}
+)
+
+// IsBreaking reports whether the footer describes a breaking change.
+func (f Footer) IsBreaking() bool {
+	return f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE"
+}
+
+func (f Footer) String() string {
+	if f.Separator == "" {
+		return f.Token + ": " + f.Value
+	}
+	return f.Token + f.Separator + f.Value
+}
+
+// String formats the message as git expects it: subject, blank line, body, blank line, footers.
+func (m Message) String() string {
+	parts := []string{m.Subject}
+	if m.Body != "" {
+		parts = append(parts, m.Body)
+	}
+	if len(m.Footers) > 0 {
+		footers := make([]string, 0, len(m.Footers))
+		for _, footer := range m.Footers {
+			footers = append(footers, footer.String())
+		}
+		parts = append(parts, strings.Join(footers, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// ParseHeader splits a conventional subject, ok is false for subjects of other forms
+func ParseHeader(subject string) (Header, bool) {
+	match := headerPattern.FindStringSubmatch(subject)
+	if match == nil || exemptPattern.MatchString(subject) {
+		return Header{}, false
+	}
+	return Header{Type: match[1], Scope: match[2], Breaking: match[3] == "!", Description: match[4]}, true
+}
+
+// IsBreaking reports whether the header or a footer marks a breaking change.
+func (m Message) IsBreaking() bool {
+	if header, ok := ParseHeader(m.Subject); ok && header.Breaking {
+		return true
+	}
+	for _, footer := range m.Footers {
+		if footer.IsBreaking() {
+			return true
+		}
+	}
+	return false
+}
+
+// WithScope returns the message with the scope of its header replaced.
+// Headers that do not follow the convention are left alone.
+func (m Message) WithScope(scope string) Message {
+	header, ok := ParseHeader(m.Subject)
+	if !ok {
+		return m
+	}
+	header.Scope = scope
+	m.Subject = header.String()
+	return m
+}
+
+func (h Header) String() string {
+	result := h.Type
+	if h.Scope != "" {
+		result += "(" + h.Scope + ")"
+	}
+	if h.Breaking {
+		result += "!"
+	}
+	return result + ": " + h.Description
+}
+
+// Clean removes markdown fences and surrounding whitespace models tend to add.
+func Clean(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	cleaned := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if fencePattern.MatchString(strings.TrimSpace(line)) {
+			continue
+		}
+		cleaned = append(cleaned, strings.TrimRight(line, " \t"))
+	}
+	return strings.Trim(strings.Join(cleaned, "\n"), "\n ")
+}
+
+// Parse splits a raw commit message into subject, body and trailing footers.
+// Body paragraphs are re-wrapped at BodyWidth.
+func Parse(raw string) Message {
+	paragraphs := splitParagraphs(Clean(raw))
+	if len(paragraphs) == 0 {
+		return Message{}
+	}
+
+	msg := Message{Subject: strings.TrimSpace(paragraphs[0][0])}
+	if len(paragraphs[0]) > 1 {
+		// body started right after the subject without a blank line
+		paragraphs[0] = paragraphs[0][1:]
+	} else {
+		paragraphs = paragraphs[1:]
+	}
+
+	if len(paragraphs) > 0 {
+		if footers, ok := parseFooters(paragraphs[len(paragraphs)-1]); ok {
+			msg.Footers = footers
+			paragraphs = paragraphs[:len(paragraphs)-1]
+		}
+	}
+
+	body := make([]string, 0, len(paragraphs))
+	for _, paragraph := range paragraphs {
+		body = append(body, wrapParagraph(paragraph, BodyWidth))
+	}
+	msg.Body = strings.Join(body, "\n\n")
+
+	return msg
+}
+
+func splitParagraphs(text string) [][]string {
+	paragraphs := make([][]string, 0)
+	current := make([]string, 0)
+	for line := range strings.SplitSeq(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				paragraphs = append(paragraphs, current)
+				current = make([]string, 0)
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, current)
+	}
+	return paragraphs
+}
+
+func parseFooters(lines []string) ([]Footer, bool) {
+	footers := make([]Footer, 0, len(lines))
+	for _, line := range lines {
+		match := footerPattern.FindStringSubmatch(line)
+		if match == nil {
+			// continuation of a multi-line footer value
+			if len(footers) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+				footers[len(footers)-1].Value += " " + strings.TrimSpace(line)
+				continue
+			}
+			return nil, false
+		}
+		footers = append(footers, Footer{Token: match[1], Value: strings.TrimSpace(match[3]), Separator: match[2]})
+	}
+	return footers, len(footers) > 0
+}
+
+// wrapParagraph re-flows a paragraph at width, keeping list items on their own lines.
+func wrapParagraph(lines []string, width int) string {
+	items := make([]string, 0)
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if isListItem(trimmed) || len(items) == 0 {
+			items = append(items, trimmed)
+			continue
+		}
+		items[len(items)-1] += " " + trimmed
+	}
+
+	wrapped := make([]string, 0, len(items))
+	for _, item := range items {
+		indent := ""
+		if isListItem(item) {
+			indent = "  "
+		}
+		wrapped = append(wrapped, wrapLine(item, width, indent))
+	}
+	return strings.Join(wrapped, "\n")
+}
+
+func isListItem(line string) bool {
+	return strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")
+}
+
+func wrapLine(text string, width int, indent string) string {
+	var result strings.Builder
+	lineLength := 0
+	for i, word := range strings.Fields(text) {
+		switch {
+		case i == 0:
+		case lineLength+1+len(word) > width:
+			result.WriteString("\n" + indent)
+			lineLength = len(indent)
+		default:
+			result.WriteString(" ")
+			lineLength++
+		}
+		result.WriteString(word)
+		lineLength += len(word)
+	}
+	return result.String()
+}
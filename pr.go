@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/ledger"
+	"github.com/wert2all/ai-commit/project"
+	"github.com/wert2all/ai-commit/ui"
+)
+
+const prUsage = "usage: ai-commit pr [<base>[..<head>]] [--template <file>]"
+
+type jsonPullRequest struct {
+	Title    string           `json:"title"`
+	Body     string           `json:"body"`
+	Base     string           `json:"base"`
+	Head     string           `json:"head"`
+	Commits  []changes.Commit `json:"commits"`
+	Provider ai.ProviderInfo  `json:"provider"`
+}
+
+// runPR describes the commits of a branch as a pull request title and Markdown body
+func runPR(config ai.Config) error {
+	if len(config.Args) > 2 {
+		return errors.New(prUsage)
+	}
+	config, err := structuredResponse(config)
+	if err != nil {
+		return err
+	}
+	base, head, err := revisionRange(config)
+	if err != nil {
+		return err
+	}
+
+	commits, err := changes.Commits(config.Directory, base, head)
+	if err != nil {
+		return withExitCode(exitNoChanges, err)
+	}
+	rangeChanges, err := changes.NewRangeChanges(config.Directory, base, head)
+	if err != nil {
+		return err
+	}
+	template, err := readTemplate(config)
+	if err != nil {
+		return err
+	}
+
+	provider, err := ai.NewProvider(config)
+	if err != nil {
+		return withExitCode(exitProvider, err)
+	}
+	contextBuilder, err := project.NewBuilder(config.Directory)
+	if err != nil {
+		return err
+	}
+	contextBuilder.ExcludePaths(config.File.Policy.ExcludePaths)
+	contextBuilder.AddRedaction(config.File.Redaction)
+	contextBuilder.AddLanguages()
+	contextBuilder.AddGitBranch()
+	contextBuilder.AddScopes(config.File.Scopes)
+	contextBuilder.AddPullRequest(rangeChanges, commits, template)
+	projectContext, err := contextBuilder.Build()
+	if err != nil {
+		return err
+	}
+
+	generated, responses, err := roundTrip(config, provider, projectContext, 1)
+	if err != nil {
+		return err
+	}
+
+	title, body, _ := strings.Cut(responses[0], "\n")
+	title = strings.TrimSpace(strings.TrimLeft(title, "# "))
+	body = strings.TrimSpace(body)
+	recordRun(config, generated, ledger.OutcomeGenerated, title)
+
+	switch {
+	case jsonOutput:
+		return printJSON(jsonPullRequest{Title: title, Body: body, Base: base, Head: head, Commits: commits, Provider: generated.providerInfo})
+	case config.Options.PrintOnly:
+		fmt.Printf("%s\n\n%s\n", title, body)
+		return nil
+	}
+
+	printFailures(generated)
+	fmt.Println(ui.NewProviderInfo(generated.providerInfo))
+	fmt.Println(ui.NewUsageInfo(generated.usage, generated.cost, generated.costKnown, generated.cached))
+	if len(generated.context.Redactions) > 0 {
+		fmt.Println(ui.NewRedactionInfo(generated.context.Redactions))
+	}
+	cardTitle := fmt.Sprintf("Pull request: %d commits since %s", len(commits), base)
+	if len(commits) == 1 {
+		cardTitle = "Pull request: 1 commit since " + base
+	}
+	fmt.Println(ui.NewCard(cardTitle, title, cardWidth))
+	fmt.Println()
+	fmt.Println(body)
+	return nil
+}
+
+// revisionRange reads "base..head" or "base" from the arguments, the base defaults to the default branch
+func revisionRange(config ai.Config) (string, string, error) {
+	spec := ""
+	if len(config.Args) > 1 {
+		spec = config.Args[1]
+	}
+	base, head, found := strings.Cut(spec, "..")
+	if !found || head == "" {
+		head = "HEAD"
+	}
+	if base == "" {
+		defaultBase, err := changes.DefaultBase(config.Directory)
+		if err != nil {
+			return "", "", err
+		}
+		base = defaultBase
+	}
+	return base, head, nil
+}
+
+// readTemplate reads --template, relative paths are relative to the repository root
+func readTemplate(config ai.Config) (string, error) {
+	if config.Options.Template == "" {
+		return "", nil
+	}
+	path := config.Options.Template
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(config.RepoRoot, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading pull request template: %v", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
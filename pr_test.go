@@ -0,0 +1,7 @@
+package main
+
+import "testing"
+
+func TestPRRefusesHeuristicProvider(t *testing.T) {
+	assertHeuristicRefused(t, runPR(heuristicConfig(t, "pr", "main..HEAD")))
+}
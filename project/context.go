@@ -3,16 +3,134 @@ package project
 import (
 	"bytes"
 	"fmt"
-	"os"
+	"maps"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/wert2all/ai-commit/changes"
 )
 
 // SystemPrompt is the standard prompt for all AI providers
-const systemPrompt = `You are an expert commit message generator. Generate a concise, descriptive, and semantically meaningful commit message strictly following the Conventional Commits specification (https://www.conventionalcommits.org/).
+const systemPrompt = conventionsPrompt + `
+
+Return ONLY the commit message without any explanations, markdown, or additional text.`
+
+// bodySystemPrompt asks for a full message with body and footers
+const bodySystemPrompt = conventionsPrompt + bodyRules + `
+
+Return ONLY the commit message (subject, body and footers) without any explanations, markdown, or additional text.`
+
+// bodyRules describe the body and footers of a full message
+const bodyRules = `
+
+BODY:
+- Separate the subject from the body with a blank line
+- Explain what changed and why, not how; wrap lines at 72 characters
+- Use short paragraphs or "- " bullet lists
+
+FOOTERS:
+- Separate footers from the body with a blank line
+- One footer per line in "Token: value" form (e.g., Refs: #123, Reviewed-by: Name)
+- Use "BREAKING CHANGE: <description>" for breaking changes`
+
+// rewriteSystemPrompt asks to fix a human written message instead of generating a new one
+const rewriteSystemPrompt = conventionsPrompt + `
+
+REWRITING:
+- You are given a commit message written by a developer that violates the rules above, and the list of violations
+- Rewrite it into a compliant message, keeping the author's intent, wording and details where possible
+- Use the staged changes to choose the correct type and scope
+- Keep any body paragraphs and footers (issue references, co-authors) of the original message
+
+Return ONLY the rewritten commit message without any explanations, markdown, or additional text.`
+
+// rewordSystemPrompt asks for a new subject of an existing commit
+const rewordSystemPrompt = conventionsPrompt + rewordRules + `
+
+Return ONLY the commit message without any explanations, markdown, or additional text.`
+
+// rewordBodySystemPrompt asks for a new full message of an existing commit
+const rewordBodySystemPrompt = conventionsPrompt + bodyRules + rewordRules + `
+
+Return ONLY the commit message (subject, body and footers) without any explanations, markdown, or additional text.`
+
+// rewordRules describe how the original message of a commit is used
+const rewordRules = `
+
+REWORDING:
+- You are given the changes of a commit that is already in the history and its original message
+- The original message is often uninformative (e.g., "wip", "fix", "more changes"); describe the changes themselves
+- Keep details of the original message that the changes cannot tell, such as the reason for the change and issue references`
+
+// splitSystemPrompt asks for a plan of several commits instead of a single message
+const splitSystemPrompt = conventionsPrompt + `
+
+SPLITTING:
+- The staged changes are given as hunks, each labelled with an id such as [H1]
+- Group the hunks into the smallest number of logical, atomic commits; unrelated changes belong to separate commits
+- Every hunk must belong to exactly one commit; keep hunks that depend on each other in the same commit
+- Order the commits so that each one builds on the previous ones
+- Write a commit message following the rules above for each commit
+
+Return ONLY a JSON object without markdown in this form:
+{"commits": [{"message": "<commit message>", "hunks": ["H1", "H3"]}]}`
+
+// pullRequestSystemPrompt asks for a pull request title and description of a whole branch
+const pullRequestSystemPrompt = conventionsPrompt + `
+
+PULL REQUEST:
+- You are given the commits of a branch and their combined changes; describe the pull request that merges them
+- The first line is the pull request title, following the commit message format above
+- After a blank line, write the description in Markdown with these sections:
+  ## Summary: what the pull request does and why, in one or two short paragraphs
+  ## Changes: a "- " bullet list of the notable changes, grouped by area
+  ## Testing: how the changes were or can be tested, based on the tests touched by the changes
+  ## Breaking changes: only when the changes break users, describing the migration
+- When a pull request template is given, fill in its sections instead, keeping its headings and their order; leave checklists unchecked
+
+Return ONLY the title and the description without any explanations or additional text, and do not wrap them in a code block.`
+
+// releaseNotesSystemPrompt asks to rewrite changelog sections for the users of a release
+const releaseNotesSystemPrompt = `You are an expert technical writer. Rewrite the changelog sections of a release, generated from Conventional Commits, into human-friendly release notes.
+
+RELEASE NOTES:
+- Write for the users of the project, not its developers: describe what they can now do, what was fixed and what they need to change
+- Keep the sections and their titles; merge entries that describe the same change and leave out purely internal ones
+- Write one short sentence per note, starting with a capital letter and ending with a period
+- Keep scopes only when they help users, and keep issue references such as #123
+- Describe every breaking change with the migration it requires
+
+Return ONLY a JSON object without markdown that maps each section title to its list of notes, e.g.:
+{"Features": ["Add dark mode to the settings page."], "Bug Fixes": ["Fix a crash when the config file is empty."]}`
+
+// branchNameSystemPrompt asks for a short subject that a branch name is derived from
+const branchNameSystemPrompt = conventionsPrompt + `
+
+BRANCH NAME:
+- The changes are work in progress that a new branch will be created for; the subject is turned into the branch name
+- Keep the description to three to six words naming the feature or fix, without filler words
+- Use a single scope at most
+
+Return ONLY the commit subject without any explanations, markdown, or additional text.`
+
+// scoreSystemPrompt asks to rate how well an existing subject describes its commit
+const scoreSystemPrompt = `You are an expert code reviewer. Rate how well the subject of a commit describes the changes of that commit.
+
+SCORING:
+- 5: precise and complete, a reader knows what changed and where without opening the diff
+- 4: accurate but vague about the area or the extent of the change
+- 3: partly accurate, misses a significant part of the changes
+- 2: too generic to be useful (e.g., "update code", "fix bug")
+- 1: unrelated to the changes or uninformative (e.g., "wip", "more changes")
+- Judge only what the subject says; its format is checked separately
+
+Return ONLY a JSON object without markdown in this form:
+{"score": 3, "reason": "<one sentence explaining the score>"}`
+
+// conventionsPrompt holds the Conventional Commits rules shared by all prompts
+const conventionsPrompt = `You are an expert commit message generator. Generate a concise, descriptive, and semantically meaningful commit message strictly following the Conventional Commits specification (https://www.conventionalcommits.org/).
 
 FORMAT: <type>[optional scope]: <description>
 
@@ -49,15 +167,28 @@ ANALYSIS PROCESS:
 1. Examine file paths and extensions to identify affected components
 2. Review code changes to determine type of change
 3. Analyze diff content to understand what functionality was modified
-4. Consider project structure to determine appropriate scope
+4. Consider project structure to determine appropriate scope; when a scope section is present, use the scope it names
 5. Check branch name for additional context
-
-Return ONLY the commit message without any explanations, markdown, or additional text.`
+6. Use the semantic changes section, when present, to name the affected functions and types; changes marked as potentially breaking usually need "!" after the type/scope`
 
 type (
 	ProjectContext struct {
 		Context      string
 		SystemPrompt string
+		// Multiline is set when a body and footers are expected in the response
+		Multiline bool
+		// MaxTokens overrides the completion budget of a message when set,
+		// for responses such as plans that are longer than a message
+		MaxTokens int
+		Changes   changes.Changes
+		// Redactions lists the secrets replaced by placeholders in Context
+		Redactions []Redaction
+		// SemanticChanges are the public symbols added, removed or modified by the changes
+		SemanticChanges []SymbolChange
+		// Scopes are inferred from the workspace units of the staged files, the most changed first
+		Scopes []string
+		// Branch is the current branch when AddGitBranch was called, empty on a detached HEAD
+		Branch string
 	}
 
 	ContextBuilder interface {
@@ -65,23 +196,134 @@ type (
 		AddLanguages()
 		AddGitBranch()
 		AddChangedFilesContent()
+		AddProjectConfig()
+		WithBody()
+		AddHint(hint string)
+		AddRewrite(original string, violations []string)
+		AddSplit(hunks []changes.Hunk)
+		AddPullRequest(rangeChanges changes.Changes, commits []changes.Commit, template string)
+		AddReleaseNotes(sections string)
+		AddReword(commitChanges changes.Changes, original string)
+		AddScore(commitChanges changes.Changes, subject string)
+		AddBranchName(branchChanges changes.Changes)
+		AddRedaction(config RedactionConfig)
+		ExcludePaths(patterns []string)
+		AddSemanticChanges()
+		AddScopes(config ScopeConfig)
 
 		Build() (*ProjectContext, error)
 	}
 	contextBuilderImpl struct {
+		dir                 string
 		files               []string
 		errors              []error
 		changes             changes.Changes
 		changedFilesContent map[string]string
-		languages           []string
+		languages           []LanguageShare
+		buildSystems        []string
+		withLanguages       bool
 		branch              *string
+		withBody            bool
+		hint                string
+		rewrite             *rewrite
+		split               []changes.Hunk
+		pullRequest         *pullRequest
+		releaseNotes        string
+		reword              *string
+		score               *string
+		branchName          bool
+		redactor            *redactor
+		excluded            []string
+		withSemantics       bool
+		scopeConfig         *ScopeConfig
+		projectConfig       []projectConfigFile
+		units               []workspaceUnit
+	}
+	rewrite struct {
+		original   string
+		violations []string
+	}
+	pullRequest struct {
+		commits  []changes.Commit
+		template string
+	}
+	projectConfigFile struct {
+		kind    string
+		path    string
+		content string
 	}
 )
 
+// longResponseMaxTokens is the completion budget of responses longer than a message
+const longResponseMaxTokens = 4096
+
+// maxProjectConfigSize is the number of bytes of a manifest included in the context
+const maxProjectConfigSize = 4096
+
+// AddRewrite implements ContextBuilder.
+func (c *contextBuilderImpl) AddRewrite(original string, violations []string) {
+	c.rewrite = &rewrite{original: original, violations: violations}
+	// keep the body and footers of multi-line originals
+	if strings.Contains(strings.TrimSpace(original), "\n") {
+		c.withBody = true
+	}
+}
+
+// AddSplit implements ContextBuilder.
+// The hunks replace the diff in the context, so the model can refer to them by id.
+func (c *contextBuilderImpl) AddSplit(hunks []changes.Hunk) {
+	c.split = hunks
+}
+
+// AddPullRequest implements ContextBuilder.
+// The changes of the range replace the staged ones.
+func (c *contextBuilderImpl) AddPullRequest(rangeChanges changes.Changes, commits []changes.Commit, template string) {
+	c.changes = rangeChanges
+	c.pullRequest = &pullRequest{commits: commits, template: template}
+}
+
+// AddReleaseNotes implements ContextBuilder.
+// The context is then only the changelog sections, without the project structure.
+func (c *contextBuilderImpl) AddReleaseNotes(sections string) {
+	c.releaseNotes = sections
+}
+
+// AddReword implements ContextBuilder.
+// The changes of the commit replace the staged ones.
+func (c *contextBuilderImpl) AddReword(commitChanges changes.Changes, original string) {
+	c.changes = commitChanges
+	c.reword = &original
+}
+
+// AddScore implements ContextBuilder.
+// The changes of the commit replace the staged ones.
+func (c *contextBuilderImpl) AddScore(commitChanges changes.Changes, subject string) {
+	c.changes = commitChanges
+	c.score = &subject
+}
+
+// AddBranchName implements ContextBuilder.
+// The given changes, staged or not, replace the staged ones.
+func (c *contextBuilderImpl) AddBranchName(branchChanges changes.Changes) {
+	c.changes = branchChanges
+	c.branchName = true
+}
+
+// AddHint implements ContextBuilder.
+func (c *contextBuilderImpl) AddHint(hint string) {
+	c.hint = strings.TrimSpace(hint)
+}
+
+// WithBody implements ContextBuilder.
+func (c *contextBuilderImpl) WithBody() {
+	c.withBody = true
+}
+
 // AddGitBranch implements ContextBuilder.
 func (c *contextBuilderImpl) AddGitBranch() {
 	// Get git branch info
 	branchCmd := exec.Command("git", "branch", "--show-current")
+	branchCmd.Dir = c.dir
 	branchOut, err := branchCmd.Output()
 	if err != nil {
 		c.errors = append(c.errors, err)
@@ -90,39 +332,9 @@ func (c *contextBuilderImpl) AddGitBranch() {
 	c.branch = &branchString
 }
 
-// AddLanguages implements ContextBuilder.
-func (c *contextBuilderImpl) AddLanguages() {
-	languages := make(map[string]bool)
-
-	for _, file := range c.files {
-		switch {
-		case strings.HasSuffix(file, ".go"):
-			languages["Go"] = true
-		case strings.HasSuffix(file, ".js") || strings.HasSuffix(file, ".ts"):
-			languages["JavaScript/TypeScript"] = true
-		case strings.HasSuffix(file, ".py"):
-			languages["Python"] = true
-		case strings.HasSuffix(file, ".php"):
-			languages["PHP"] = true
-		case strings.HasSuffix(file, ".java"):
-			languages["Java"] = true
-		case strings.HasSuffix(file, ".rb"):
-			languages["Ruby"] = true
-		case strings.HasSuffix(file, ".rs"):
-			languages["Rust"] = true
-		case strings.HasSuffix(file, ".kt"):
-			languages["Kotlin"] = true
-		}
-	}
-
-	for lang := range languages {
-		c.languages = append(c.languages, lang)
-	}
-}
-
 // AddChanges implements ContextBuilder.
 func (c *contextBuilderImpl) AddChanges() {
-	changes, err := changes.NewChanges()
+	changes, err := changes.NewChanges(c.dir)
 	if err != nil {
 		c.errors = append(c.errors, err)
 	}
@@ -136,83 +348,293 @@ func (c *contextBuilderImpl) Build() (*ProjectContext, error) {
 	}
 	var context bytes.Buffer
 
-	context.WriteString("\n=== Project Structure ===\n")
-	for _, file := range c.files {
-		context.WriteString(file + "\n")
+	// release notes are written from the changelog alone, the file list adds nothing to them
+	if c.releaseNotes == "" {
+		context.WriteString("\n=== Project Structure ===\n")
+		for _, file := range c.files {
+			context.WriteString(file + "\n")
+		}
 	}
 
 	if len(c.languages) > 0 {
 		context.WriteString("\n=== Project Languages ===\n")
-		for _, lang := range c.languages {
-			context.WriteString(lang + "\n")
+		context.WriteString(formatLanguageShares(c.languages))
+	}
+
+	if c.withLanguages && c.changes != nil {
+		if changed := c.changedLanguages(); len(changed) > 0 {
+			context.WriteString("\n=== Changed files languages ===\n")
+			context.WriteString(formatLanguageShares(changed))
 		}
 	}
 
+	if len(c.buildSystems) > 0 {
+		context.WriteString("\n=== Build systems and frameworks ===\n")
+		context.WriteString(strings.Join(c.buildSystems, ", ") + "\n")
+	}
+
 	if c.branch != nil {
 		context.WriteString("\n=== Git branch ===\n")
 		context.WriteString(*c.branch)
 	}
 
-	if c.changes != nil {
+	if c.split != nil {
+		context.WriteString("\n=== Hunks ===\n")
+		for _, hunk := range c.split {
+			text := hunk.Text()
+			if c.isExcluded(hunk.Path) {
+				text = excludedPlaceholder + "\n"
+			} else if c.redactor != nil {
+				text = c.redactor.redactFile(hunk.Path, text)
+			}
+			context.WriteString(fmt.Sprintf("\n[%s] %s\n", hunk.ID, hunk.Path))
+			context.WriteString(text)
+		}
+	} else if c.changes != nil {
 		context.WriteString("\n=== Changes ===\n")
-		context.Write(c.changes.Diff())
+		diff := c.excludeDiff(string(c.changes.Diff()))
+		if c.redactor != nil {
+			diff = c.redactor.redactDiff(diff)
+		}
+		context.WriteString(diff)
+	}
+
+	semanticChanges := make([]SymbolChange, 0)
+	if c.withSemantics && c.changes != nil {
+		semanticChanges = c.semanticChanges()
+	}
+	if len(semanticChanges) > 0 {
+		context.WriteString("\n=== Semantic changes ===\n")
+		section := formatSemanticChanges(semanticChanges)
+		if c.redactor != nil {
+			section = c.redactor.redact("semantic changes", section)
+		}
+		context.WriteString(section)
+	}
+
+	scopes := make([]string, 0)
+	if c.scopeConfig != nil && c.changes != nil {
+		scopes = c.inferScopes()
+	}
+	if len(scopes) > 0 {
+		context.WriteString("\n=== Scope ===\n")
+		context.WriteString(formatScopes(scopes))
+	}
 
+	if len(c.projectConfig) > 0 {
+		context.WriteString("\n=== Project configuration ===\n")
+		for _, file := range c.projectConfig {
+			content := file.content
+			if c.isExcluded(file.path) {
+				content = excludedPlaceholder + "\n"
+			} else if c.redactor != nil {
+				content = c.redactor.redactFile(file.path, content)
+			}
+			// cut after redacting, a secret cut in half would no longer match its rule
+			content = truncateText(content, maxProjectConfigSize)
+			context.WriteString(fmt.Sprintf("\n== %s: %s ==\n", file.kind, file.path))
+			context.WriteString(content)
+		}
 	}
 
 	if len(c.changedFilesContent) > 0 {
 		context.WriteString("\n=== Changed files content ===\n")
-		for filename, content := range c.changedFilesContent {
+		// sorted so that identical changes give identical contexts, as the cache keys on them
+		for _, filename := range slices.Sorted(maps.Keys(c.changedFilesContent)) {
+			content := c.changedFilesContent[filename]
+			if c.isExcluded(filename) {
+				content = excludedPlaceholder + "\n"
+			} else if c.redactor != nil {
+				content = c.redactor.redactFile(filename, content)
+			}
 			fileHeader := fmt.Sprintf("\n== Filename: %s ==\n", filename)
 			context.WriteString(fileHeader)
 			context.WriteString(content)
 		}
 	}
 
+	if c.hint != "" {
+		context.WriteString("\n=== Additional instructions ===\n")
+		context.WriteString(c.hint + "\n")
+	}
+
+	prompt := systemPrompt
+	if c.withBody {
+		prompt = bodySystemPrompt
+	}
+
+	if c.rewrite != nil {
+		context.WriteString("\n=== Original commit message ===\n")
+		context.WriteString(c.rewrite.original + "\n")
+		context.WriteString("\n=== Violations ===\n")
+		for _, violation := range c.rewrite.violations {
+			context.WriteString(violation + "\n")
+		}
+		prompt = rewriteSystemPrompt
+	}
+
+	if c.reword != nil {
+		section := *c.reword
+		if c.redactor != nil {
+			section = c.redactor.redact("commit messages", section)
+		}
+		context.WriteString("\n=== Original commit message ===\n")
+		context.WriteString(section + "\n")
+		prompt = rewordSystemPrompt
+		if c.withBody {
+			prompt = rewordBodySystemPrompt
+		}
+	}
+
+	if c.score != nil {
+		section := *c.score
+		if c.redactor != nil {
+			section = c.redactor.redact("commit messages", section)
+		}
+		context.WriteString("\n=== Commit subject ===\n")
+		context.WriteString(section + "\n")
+		prompt = scoreSystemPrompt
+	}
+
+	if c.branchName {
+		prompt = branchNameSystemPrompt
+	}
+
+	if c.split != nil {
+		prompt = splitSystemPrompt
+	}
+
+	if c.pullRequest != nil {
+		var commits strings.Builder
+		for _, commit := range c.pullRequest.commits {
+			commits.WriteString("- " + commit.Subject + "\n")
+			if commit.Body != "" {
+				commits.WriteString("  " + strings.ReplaceAll(commit.Body, "\n", "\n  ") + "\n")
+			}
+		}
+		section := commits.String()
+		if c.redactor != nil {
+			section = c.redactor.redact("commit messages", section)
+		}
+		context.WriteString("\n=== Commits ===\n")
+		context.WriteString(section)
+		if c.pullRequest.template != "" {
+			context.WriteString("\n=== Pull request template ===\n")
+			context.WriteString(c.pullRequest.template + "\n")
+		}
+		prompt = pullRequestSystemPrompt
+	}
+
+	if c.releaseNotes != "" {
+		section := c.releaseNotes
+		if c.redactor != nil {
+			section = c.redactor.redact("changelog", section)
+		}
+		context.WriteString("\n=== Changelog ===\n")
+		context.WriteString(section)
+		prompt = releaseNotesSystemPrompt
+	}
+
+	redactions := make([]Redaction, 0)
+	if c.redactor != nil {
+		redactions = c.redactor.report()
+	}
+
+	branch := ""
+	if c.branch != nil {
+		branch = strings.TrimSpace(*c.branch)
+	}
+
+	maxTokens := 0
+	if c.longResponse() {
+		maxTokens = longResponseMaxTokens
+	}
+
 	return &ProjectContext{
-		Context:      context.String(),
-		SystemPrompt: systemPrompt,
+		Context:         context.String(),
+		SystemPrompt:    prompt,
+		Multiline:       c.withBody || c.longResponse(),
+		MaxTokens:       maxTokens,
+		Changes:         c.changes,
+		Redactions:      redactions,
+		SemanticChanges: semanticChanges,
+		Scopes:          scopes,
+		Branch:          branch,
 	}, nil
 }
 
-func readFileContent(path string) string {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return ""
-	}
-	return string(content)
+// longResponse reports whether the response is a split plan, a pull request description or
+// release notes rather than a message, which spans many lines whatever --body says
+func (c *contextBuilderImpl) longResponse() bool {
+	return c.split != nil || c.pullRequest != nil || c.releaseNotes != ""
 }
 
-// nolint
-func getProjectConfig(repoRoot string, files []string) map[string]string {
-	config := make(map[string]string)
+// AddProjectConfig implements ContextBuilder.
+// Only manifests touched by the changes are included, as staged; Build cuts them to maxProjectConfigSize.
+func (c *contextBuilderImpl) AddProjectConfig() {
+	if c.changes == nil {
+		return
+	}
+	c.projectConfig = make([]projectConfigFile, 0)
+	for _, file := range c.changes.Files() {
+		kind := projectConfigKind(file.Path)
+		if kind == "" || file.Status == changes.FileDeleted {
+			continue
+		}
+		content, ok := c.gitShow(":" + file.Path)
+		if !ok {
+			continue
+		}
+		c.projectConfig = append(c.projectConfig, projectConfigFile{kind: kind, path: file.Path, content: string(content)})
+	}
+}
 
-	// Check for various config files
-	for _, file := range files {
-		fullPath := filepath.Join(repoRoot, file)
-		switch filepath.Base(file) {
-		case ".env":
-			config["Environment Config"] = readFileContent(fullPath)
-		case "docker-compose.yml", "docker-compose.yaml":
-			config["Docker Compose Config"] = readFileContent(fullPath)
-		case "Dockerfile":
-			config["Dockerfile"] = readFileContent(fullPath)
-		case ".gitlab-ci.yml", ".github/workflows/":
-			config["CI/CD Config"] = readFileContent(fullPath)
-		case "nginx.conf":
-			config["Nginx Config"] = readFileContent(fullPath)
-		case "webpack.config.js":
-			config["Webpack Config"] = readFileContent(fullPath)
+// truncateText cuts text to at most size bytes at the end of a line, or between runes
+// when the first line is longer, and tells how much was left out
+func truncateText(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	cut := strings.LastIndex(text[:size+1], "\n") + 1
+	if cut == 0 {
+		for i := range text {
+			if i > size {
+				break
+			}
+			cut = i
 		}
 	}
+	return fmt.Sprintf("%s\n... (%d more bytes)\n", strings.TrimRight(text[:cut], "\n"), len(text)-cut)
+}
 
-	return config
+// projectConfigKind names the build, CI or container manifests worth describing to the model
+func projectConfigKind(file string) string {
+	name := filepath.Base(file)
+	switch {
+	case name == "Dockerfile" || name == "Containerfile" || strings.HasSuffix(name, ".dockerfile") || strings.HasPrefix(name, "Dockerfile."):
+		return "Dockerfile"
+	case name == "docker-compose.yml" || name == "docker-compose.yaml" || name == "compose.yml" || name == "compose.yaml":
+		return "Docker Compose"
+	case MatchPath(".github/workflows/", file) || name == ".gitlab-ci.yml" || name == "Jenkinsfile" || name == ".travis.yml":
+		return "CI/CD"
+	case name == "Makefile" || name == "GNUmakefile":
+		return "Makefile"
+	case name == "go.mod":
+		return "Go module"
+	case name == "package.json":
+		return "npm package"
+	}
+	return ""
 }
 
+// AddChangedFilesContent implements ContextBuilder.
+// Files are read as staged, so unstaged edits stay out; deleted files are left empty.
 func (c *contextBuilderImpl) AddChangedFilesContent() {
 	changedFiles := c.changes.ChangedFiles()
 	c.changedFilesContent = make(map[string]string, 0)
 	for _, file := range changedFiles {
-		c.changedFilesContent[file] = readFileContent(file)
+		content, _ := c.gitShow(":" + file)
+		c.changedFilesContent[file] = string(content)
 	}
 }
 
@@ -237,9 +659,10 @@ func NewBuilder(projectDir string) (ContextBuilder, error) {
 	}
 
 	return &contextBuilderImpl{
+		dir:                 projectDir,
 		errors:              make([]error, 0),
 		files:               files,
-		languages:           make([]string, 0),
+		languages:           make([]LanguageShare, 0),
 		branch:              nil,
 		changes:             nil,
 		changedFilesContent: map[string]string{},
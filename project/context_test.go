@@ -0,0 +1,81 @@
+package project
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+}
+
+// the tests run in the package directory, so a temporary repository is like --dir
+func TestChangedFilesContentIsReadAsStagedFromDir(t *testing.T) {
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("staged line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", "notes.txt")
+	if err := os.WriteFile(path, []byte("staged line\nunstaged line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	builder, err := NewBuilder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder.AddChanges()
+	builder.AddChangedFilesContent()
+	projectContext, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, content, _ := strings.Cut(projectContext.Context, "== Filename: notes.txt ==\n")
+	if !strings.HasPrefix(content, "staged line\n") {
+		t.Errorf("context lacks the staged content:\n%s", projectContext.Context)
+	}
+	if strings.Contains(content, "unstaged line") {
+		t.Errorf("context contains unstaged content:\n%s", projectContext.Context)
+	}
+}
+
+func TestReleaseNotesContextIsTheChangelogOnly(t *testing.T) {
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", "main.go")
+
+	builder, err := NewBuilder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder.AddReleaseNotes("## Unreleased\n\n### Features\n\n- **ui:** add dark mode (abc1234)\n")
+	projectContext, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\n=== Changelog ===\n## Unreleased\n\n### Features\n\n- **ui:** add dark mode (abc1234)\n"
+	if projectContext.Context != want {
+		t.Errorf("context =\n%q\nwant\n%q", projectContext.Context, want)
+	}
+	if projectContext.SystemPrompt != releaseNotesSystemPrompt {
+		t.Errorf("system prompt =\n%s\nwant the release notes prompt", projectContext.SystemPrompt)
+	}
+	if !projectContext.Multiline || projectContext.MaxTokens != longResponseMaxTokens {
+		t.Errorf("release notes get Multiline %v and MaxTokens %d, want a long response", projectContext.Multiline, projectContext.MaxTokens)
+	}
+}
@@ -0,0 +1,74 @@
+package project
+
+import (
+	"path"
+	"strings"
+)
+
+// excludedPlaceholder replaces the lines of files excluded from the context
+const excludedPlaceholder = "<excluded by policy>"
+
+// MatchPath reports whether a repository path matches a pattern.
+// "dir/" and "dir/**" match everything below dir, patterns with a slash are matched
+// against the whole path and patterns without one against every path segment.
+func MatchPath(pattern, filePath string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	filePath = strings.TrimPrefix(filePath, "/")
+
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		pattern = dir + "/"
+	}
+	if strings.HasSuffix(pattern, "/") {
+		for dir := path.Dir(filePath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if MatchPath(strings.TrimSuffix(pattern, "/"), dir) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, filePath)
+		return matched
+	}
+	for segment := range strings.SplitSeq(filePath, "/") {
+		if matched, _ := path.Match(pattern, segment); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAny reports whether the path matches one of the patterns
+func MatchAny(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		if MatchPath(pattern, filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExcludePaths implements ContextBuilder.
+func (c *contextBuilderImpl) ExcludePaths(patterns []string) {
+	c.excluded = append(c.excluded, patterns...)
+}
+
+func (c *contextBuilderImpl) isExcluded(filePath string) bool {
+	return MatchAny(c.excluded, filePath)
+}
+
+// excludeDiff keeps the headers of excluded files, hunk headers cut to their line ranges,
+// and drops their changed lines
+func (c *contextBuilderImpl) excludeDiff(diff string) string {
+	if len(c.excluded) == 0 {
+		return diff
+	}
+	sections := strings.SplitAfter(diff, "\ndiff --git ")
+	for i, section := range sections {
+		if c.isExcluded(diffSectionPath(section, i == 0)) {
+			sections[i] = hideDiffLines(section, excludedPlaceholder)
+		}
+	}
+	return strings.Join(sections, "")
+}
@@ -0,0 +1,41 @@
+package project
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExcludeDiffHidesHunkHeaderContext(t *testing.T) {
+	diff := `diff --git a/legal/contract.md b/legal/contract.md
+index 1111111..2222222 100644
+--- a/legal/contract.md
++++ b/legal/contract.md
+@@ -10,3 +10,3 @@ The licensee pays ACME Corp 120000 EUR per year
+ Terms apply.
+-Net 30.
++Net 60.
+diff --git a/main.go b/main.go
+index 3333333..4444444 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@ package main
+-var x = 1
++var x = 2
+`
+	c := &contextBuilderImpl{}
+	c.ExcludePaths([]string{"legal/"})
+	excluded := c.excludeDiff(diff)
+
+	for _, text := range []string{"ACME", "120000", "Net 30", "Net 60"} {
+		if strings.Contains(excluded, text) {
+			t.Errorf("excluded diff contains %q:\n%s", text, excluded)
+		}
+	}
+	if !strings.Contains(excluded, "@@ -10,3 +10,3 @@\n "+excludedPlaceholder) {
+		t.Errorf("excluded section lacks its line range and placeholder:\n%s", excluded)
+	}
+	// files that are not excluded keep their headers as git wrote them
+	if !strings.Contains(excluded, "@@ -1,3 +1,3 @@ package main\n-var x = 1") {
+		t.Errorf("included section was changed:\n%s", excluded)
+	}
+}
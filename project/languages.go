@@ -0,0 +1,449 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxCountedFileSize skips files too large to be written by hand
+	maxCountedFileSize = 1 << 20
+	maxListedLanguages = 10
+)
+
+type (
+	// languageKind follows linguist, only programming and markup languages are counted
+	languageKind int
+	language     struct {
+		name         string
+		kind         languageKind
+		extensions   []string
+		filenames    []string
+		interpreters []string
+	}
+	// LanguageShare is the number of lines of a language, changed lines for the staged files
+	LanguageShare struct {
+		Name    string  `json:"name"`
+		Lines   int     `json:"lines"`
+		Percent float64 `json:"percent"`
+	}
+	// linguistAttributes are the linguist overrides of a file set in .gitattributes
+	linguistAttributes struct {
+		language string
+		ignored  bool
+	}
+	// buildSystem is detected by a marker file, frameworks by the dependencies it declares
+	buildSystem struct {
+		name       string
+		marker     string
+		frameworks func(content []byte) []string
+	}
+)
+
+const (
+	programming languageKind = iota
+	markup
+	data
+	prose
+)
+
+var languageTable = []language{
+	{name: "Go", extensions: []string{".go"}},
+	{name: "TypeScript", extensions: []string{".ts", ".tsx", ".mts", ".cts"}, interpreters: []string{"ts-node", "deno", "tsx"}},
+	{name: "JavaScript", extensions: []string{".js", ".jsx", ".mjs", ".cjs"}, interpreters: []string{"node", "nodejs"}},
+	{name: "Python", extensions: []string{".py", ".pyi", ".pyw"}, filenames: []string{"SConstruct", "SConscript"}, interpreters: []string{"python"}},
+	{name: "Java", extensions: []string{".java"}},
+	{name: "Kotlin", extensions: []string{".kt", ".kts"}},
+	{name: "Scala", extensions: []string{".scala", ".sc"}},
+	{name: "Groovy", extensions: []string{".groovy", ".gradle"}, filenames: []string{"Jenkinsfile"}},
+	{name: "Rust", extensions: []string{".rs"}},
+	{name: "C", extensions: []string{".c", ".h"}},
+	{name: "C++", extensions: []string{".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx"}},
+	{name: "C#", extensions: []string{".cs", ".csx"}},
+	{name: "Objective-C", extensions: []string{".m", ".mm"}},
+	{name: "Swift", extensions: []string{".swift"}},
+	{name: "Dart", extensions: []string{".dart"}},
+	{name: "PHP", extensions: []string{".php", ".phtml"}, interpreters: []string{"php"}},
+	{name: "Ruby", extensions: []string{".rb", ".rake", ".gemspec"}, filenames: []string{"Rakefile", "Gemfile", "Vagrantfile"}, interpreters: []string{"ruby"}},
+	{name: "Perl", extensions: []string{".pl", ".pm"}, interpreters: []string{"perl"}},
+	{name: "Lua", extensions: []string{".lua"}, interpreters: []string{"lua"}},
+	{name: "R", extensions: []string{".r", ".R"}, interpreters: []string{"Rscript"}},
+	{name: "Elixir", extensions: []string{".ex", ".exs"}, interpreters: []string{"elixir"}},
+	{name: "Erlang", extensions: []string{".erl", ".hrl"}},
+	{name: "Haskell", extensions: []string{".hs", ".lhs"}},
+	{name: "Clojure", extensions: []string{".clj", ".cljs", ".cljc"}},
+	{name: "Zig", extensions: []string{".zig"}},
+	{name: "Shell", extensions: []string{".sh", ".bash", ".zsh", ".ksh"}, interpreters: []string{"sh", "bash", "zsh", "ksh", "dash"}},
+	{name: "PowerShell", extensions: []string{".ps1", ".psm1"}, interpreters: []string{"pwsh"}},
+	{name: "SQL", extensions: []string{".sql"}},
+	{name: "Dockerfile", extensions: []string{".dockerfile"}, filenames: []string{"Dockerfile", "Containerfile"}},
+	{name: "Makefile", extensions: []string{".mk", ".mak"}, filenames: []string{"Makefile", "makefile", "GNUmakefile"}},
+	{name: "CMake", extensions: []string{".cmake"}, filenames: []string{"CMakeLists.txt"}},
+	{name: "HCL", extensions: []string{".tf", ".hcl", ".tfvars"}},
+	{name: "Nix", extensions: []string{".nix"}},
+	{name: "Protocol Buffer", extensions: []string{".proto"}},
+	{name: "Vue", extensions: []string{".vue"}, kind: markup},
+	{name: "Svelte", extensions: []string{".svelte"}, kind: markup},
+	{name: "HTML", extensions: []string{".html", ".htm"}, kind: markup},
+	{name: "CSS", extensions: []string{".css"}, kind: markup},
+	{name: "SCSS", extensions: []string{".scss", ".sass"}, kind: markup},
+	{name: "JSON", extensions: []string{".json"}, kind: data},
+	{name: "YAML", extensions: []string{".yml", ".yaml"}, kind: data},
+	{name: "TOML", extensions: []string{".toml"}, kind: data},
+	{name: "XML", extensions: []string{".xml", ".svg"}, kind: data},
+	{name: "Markdown", extensions: []string{".md", ".markdown", ".mdx"}, kind: prose},
+	{name: "reStructuredText", extensions: []string{".rst"}, kind: prose},
+	{name: "Text", extensions: []string{".txt"}, kind: prose},
+}
+
+// vendoredPatterns are third-party and generated paths linguist leaves out by default
+var vendoredPatterns = []string{
+	"vendor/", "node_modules/", "third_party/", "dist/",
+	"*.min.js", "*.min.css", "*.pb.go", "*_generated.go", "*.lock", "package-lock.json", "go.sum",
+}
+
+var buildSystems = []buildSystem{
+	{name: "Go modules", marker: "go.mod", frameworks: goFrameworks},
+	{name: "Go workspace", marker: "go.work"},
+	{name: "npm", marker: "package.json", frameworks: npmFrameworks},
+	{name: "pnpm workspaces", marker: "pnpm-workspace.yaml"},
+	{name: "Cargo", marker: "Cargo.toml", frameworks: cargoFrameworks},
+	{name: "Gradle", marker: "build.gradle"},
+	{name: "Gradle", marker: "build.gradle.kts"},
+	{name: "Maven", marker: "pom.xml"},
+	{name: "Composer", marker: "composer.json", frameworks: dependencyFrameworks(map[string]string{
+		"laravel/framework": "Laravel", "symfony/framework-bundle": "Symfony",
+	})},
+	{name: "Bundler", marker: "Gemfile", frameworks: dependencyFrameworks(map[string]string{
+		"rails": "Rails", "sinatra": "Sinatra",
+	})},
+	{name: "Python packaging", marker: "pyproject.toml", frameworks: pythonFrameworks},
+	{name: "pip", marker: "requirements.txt", frameworks: pythonFrameworks},
+	{name: "CMake", marker: "CMakeLists.txt"},
+	{name: "Make", marker: "Makefile"},
+	{name: "Docker", marker: "Dockerfile"},
+}
+
+var (
+	goModules = map[string]string{
+		"github.com/gin-gonic/gin": "Gin", "github.com/labstack/echo": "Echo", "github.com/gofiber/fiber": "Fiber",
+		"github.com/go-chi/chi": "chi", "github.com/spf13/cobra": "Cobra", "github.com/charmbracelet/bubbletea": "Bubble Tea",
+		"google.golang.org/grpc": "gRPC", "gorm.io/gorm": "GORM",
+	}
+	npmPackages = map[string]string{
+		"react": "React", "next": "Next.js", "vue": "Vue", "nuxt": "Nuxt", "@angular/core": "Angular",
+		"svelte": "Svelte", "express": "Express", "@nestjs/core": "NestJS", "vite": "Vite", "jest": "Jest",
+		"vitest": "Vitest", "electron": "Electron",
+	}
+	cargoCrates = map[string]string{
+		"tokio": "Tokio", "actix-web": "Actix Web", "axum": "Axum", "rocket": "Rocket", "serde": "Serde", "clap": "clap",
+	}
+	pythonPackages = map[string]string{
+		"django": "Django", "flask": "Flask", "fastapi": "FastAPI", "pytest": "pytest", "numpy": "NumPy", "pandas": "pandas",
+	}
+)
+
+// AddLanguages implements ContextBuilder.
+// The changed files breakdown is computed in Build, once the changes are known.
+func (c *contextBuilderImpl) AddLanguages() {
+	attributes := c.linguistAttributes(c.files)
+
+	lines := make(map[string]int)
+	for _, file := range c.files {
+		lang, ok := c.detectLanguage(file, attributes)
+		if !ok {
+			continue
+		}
+		if count, ok := countLines(filepath.Join(c.dir, file)); ok {
+			lines[lang] += count
+		}
+	}
+	c.languages = languageShares(lines)
+	c.buildSystems = c.detectBuildSystems()
+	c.withLanguages = true
+}
+
+// changedLanguages breaks the staged changes down by language using the added and deleted lines
+func (c *contextBuilderImpl) changedLanguages() []LanguageShare {
+	files := c.changes.Files()
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	attributes := c.linguistAttributes(paths)
+
+	lines := make(map[string]int)
+	for _, file := range files {
+		if lang, ok := c.detectLanguage(file.Path, attributes); ok {
+			lines[lang] += file.Added + file.Deleted
+		}
+	}
+	return languageShares(lines)
+}
+
+// detectLanguage tries the .gitattributes override, the file name, the extension and the shebang in turn.
+// Vendored, generated, data and prose files are not counted.
+func (c *contextBuilderImpl) detectLanguage(file string, attributes map[string]linguistAttributes) (string, bool) {
+	if override := attributes[file]; override.ignored {
+		return "", false
+	} else if override.language != "" {
+		return override.language, true
+	}
+	if MatchAny(vendoredPatterns, file) {
+		return "", false
+	}
+
+	name := path.Base(file)
+	ext := path.Ext(name)
+	for _, lang := range languageTable {
+		if slices.Contains(lang.filenames, name) || (ext != "" && slices.Contains(lang.extensions, ext)) {
+			return lang.name, lang.kind <= markup
+		}
+	}
+	if ext != "" {
+		return "", false
+	}
+
+	interpreter := shebangInterpreter(filepath.Join(c.dir, file))
+	for _, lang := range languageTable {
+		if interpreter != "" && slices.Contains(lang.interpreters, interpreter) {
+			return lang.name, true
+		}
+	}
+	return "", false
+}
+
+// linguistAttributes reads the linguist attributes of the files with git check-attr,
+// so .gitattributes files are applied the way git applies them
+func (c *contextBuilderImpl) linguistAttributes(files []string) map[string]linguistAttributes {
+	attributes := make(map[string]linguistAttributes)
+	if len(files) == 0 {
+		return attributes
+	}
+
+	cmd := exec.Command("git", "check-attr", "-z", "--stdin",
+		"linguist-language", "linguist-vendored", "linguist-generated", "linguist-documentation", "linguist-detectable")
+	cmd.Dir = c.dir
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\x00"))
+	output, err := cmd.Output()
+	if err != nil {
+		return attributes
+	}
+
+	// the output is a sequence of NUL terminated path, attribute and value triples
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		file, attribute, value := fields[i], fields[i+1], fields[i+2]
+		if value == "unspecified" {
+			continue
+		}
+		current := attributes[file]
+		switch attribute {
+		case "linguist-language":
+			if value != "unset" && value != "set" {
+				current.language = value
+			}
+		case "linguist-detectable":
+			current.ignored = current.ignored || value == "unset" || value == "false"
+		default:
+			current.ignored = current.ignored || value == "set" || value == "true"
+		}
+		attributes[file] = current
+	}
+	return attributes
+}
+
+// detectBuildSystems looks for marker files at any depth, so nested modules of monorepos are found too
+func (c *contextBuilderImpl) detectBuildSystems() []string {
+	found := make([]string, 0)
+	add := func(names ...string) {
+		for _, name := range names {
+			if !slices.Contains(found, name) {
+				found = append(found, name)
+			}
+		}
+	}
+
+	for _, system := range buildSystems {
+		for _, file := range c.files {
+			if path.Base(file) != system.marker || MatchAny(vendoredPatterns, file) {
+				continue
+			}
+			add(system.name)
+			if system.frameworks == nil {
+				continue
+			}
+			if content, err := os.ReadFile(filepath.Join(c.dir, file)); err == nil {
+				add(system.frameworks(content)...)
+			}
+		}
+	}
+	return found
+}
+
+func goFrameworks(content []byte) []string {
+	result := make([]string, 0)
+	for _, module := range sortedKeys(goModules) {
+		if bytes.Contains(content, []byte(module)) {
+			result = append(result, goModules[module])
+		}
+	}
+	return result
+}
+
+// npmFrameworks reads the dependencies of a package.json, a workspaces key makes it an npm workspace root
+func npmFrameworks(content []byte) []string {
+	var manifest struct {
+		Workspaces      json.RawMessage   `json:"workspaces"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil
+	}
+	result := make([]string, 0)
+	if len(manifest.Workspaces) > 0 {
+		result = append(result, "npm workspaces")
+	}
+	for _, name := range sortedKeys(npmPackages) {
+		_, dependency := manifest.Dependencies[name]
+		_, devDependency := manifest.DevDependencies[name]
+		if dependency || devDependency {
+			result = append(result, npmPackages[name])
+		}
+	}
+	return result
+}
+
+func cargoFrameworks(content []byte) []string {
+	result := make([]string, 0)
+	if bytes.Contains(content, []byte("[workspace]")) {
+		result = append(result, "Cargo workspace")
+	}
+	return append(result, dependencyFrameworks(cargoCrates)(content)...)
+}
+
+func pythonFrameworks(content []byte) []string {
+	return dependencyFrameworks(pythonPackages)(bytes.ToLower(content))
+}
+
+// dependencyFrameworks matches quoted or line leading package names, enough for manifests without a parser at hand
+func dependencyFrameworks(packages map[string]string) func(content []byte) []string {
+	return func(content []byte) []string {
+		result := make([]string, 0)
+		for _, name := range sortedKeys(packages) {
+			quoted := bytes.Contains(content, []byte(`"`+name+`"`)) || bytes.Contains(content, []byte(`'`+name+`'`))
+			if quoted || startsLine(content, name) {
+				result = append(result, packages[name])
+			}
+		}
+		return result
+	}
+}
+
+func startsLine(content []byte, name string) bool {
+	for line := range strings.SplitSeq(string(content), "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), name)
+		if ok && (rest == "" || strings.ContainsAny(rest[:1], " =<>~![")) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func countLines(filename string) (int, bool) {
+	info, err := os.Stat(filename)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxCountedFileSize {
+		return 0, false
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil || bytes.IndexByte(content, 0) >= 0 {
+		return 0, false
+	}
+	count := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		count++
+	}
+	return count, true
+}
+
+// shebangInterpreter returns the program of a "#!" line, looking through env
+func shebangInterpreter(filename string) string {
+	file, err := os.Open(filename)
+	if err != nil {
+		return ""
+	}
+	// nolint
+	defer file.Close()
+
+	line, _ := bufio.NewReader(file).ReadString('\n')
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = field
+				break
+			}
+		}
+	}
+	// python3.12 and python3 are both python
+	return strings.TrimRight(interpreter, "0123456789.")
+}
+
+// languageShares sorts the languages by lines and computes their percentages
+func languageShares(lines map[string]int) []LanguageShare {
+	total := 0
+	for _, count := range lines {
+		total += count
+	}
+	result := make([]LanguageShare, 0, len(lines))
+	for name, count := range lines {
+		if count > 0 {
+			result = append(result, LanguageShare{Name: name, Lines: count, Percent: float64(count) * 100 / float64(total)})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Lines != result[j].Lines {
+			return result[i].Lines > result[j].Lines
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func formatLanguageShares(shares []LanguageShare) string {
+	var result strings.Builder
+	for i, share := range shares {
+		if i == maxListedLanguages {
+			result.WriteString(fmt.Sprintf("and %d more\n", len(shares)-i))
+			break
+		}
+		result.WriteString(fmt.Sprintf("%s: %.1f%% (%d lines)\n", share.Name, share.Percent, share.Lines))
+	}
+	return result.String()
+}
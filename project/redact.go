@@ -0,0 +1,311 @@
+package project
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultEntropyThreshold = 4.5
+	// entropyRule names secrets found by their randomness rather than a pattern
+	entropyRule    = "high-entropy"
+	secretFileRule = "secret-file"
+)
+
+// hunkHeaderPattern matches the line ranges of a hunk header without the function context
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+type (
+	// RedactionRule matches a secret, only the "secret" group is replaced when the pattern has one
+	RedactionRule struct {
+		Name    string `json:"name"`
+		Pattern string `json:"pattern"`
+	}
+	RedactionConfig struct {
+		Enabled bool            `json:"enabled"`
+		Rules   []RedactionRule `json:"rules"`
+		// EntropyThreshold in bits per character above which long tokens are redacted, 0 disables it
+		EntropyThreshold float64 `json:"entropy_threshold"`
+		// BlockRemote refuses to send a context with secrets to anything but the local provider
+		BlockRemote bool `json:"block_remote"`
+	}
+	// Redaction reports how many secrets a rule replaced in one file
+	Redaction struct {
+		Rule  string `json:"rule"`
+		Path  string `json:"path"`
+		Count int    `json:"count"`
+	}
+	redactor struct {
+		rules            []compiledRule
+		entropyThreshold float64
+		found            map[Redaction]int
+	}
+	compiledRule struct {
+		name    string
+		pattern *regexp.Regexp
+	}
+)
+
+var (
+	builtinRedactionRules = []RedactionRule{
+		{Name: "private-key", Pattern: `-----BEGIN[A-Z ]*PRIVATE KEY-----[\s\S]*?-----END[A-Z ]*PRIVATE KEY-----`},
+		{Name: "aws-access-key", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+		{Name: "github-token", Pattern: `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`},
+		{Name: "slack-token", Pattern: `\bxox[abposr]-[A-Za-z0-9-]{10,}`},
+		{Name: "api-key", Pattern: `\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`},
+		{Name: "google-api-key", Pattern: `\bAIza[0-9A-Za-z_-]{35}\b`},
+		{Name: "stripe-key", Pattern: `\b[sr]k_live_[0-9A-Za-z]{20,}\b`},
+		{Name: "jwt", Pattern: `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`},
+		{Name: "url-credentials", Pattern: `[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:(?P<secret>[^/\s@]+)@`},
+		{Name: "password", Pattern: `(?i)(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key)[\w.-]*["']?\s*[:=]+\s*["'](?P<secret>[^"'\s]{6,})["']`},
+		{Name: "password", Pattern: `(?im)^[+ -]?\s*(?:export\s+)?[\w.-]*(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key)[\w.-]*\s*[:=]\s*(?P<secret>[^\s"'$][^\s"']{5,})\s*$`},
+	}
+	// entropyCandidate matches tokens long enough to be keys or tokens
+	entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_=-]{20,}`)
+	placeholderRegex = regexp.MustCompile(`<redacted:[^<>]+>`)
+	secretFileNames  = []string{".env", "id_rsa", "id_dsa", "id_ecdsa", "id_ed25519", ".netrc", ".pgpass", "credentials"}
+	secretFileExts   = []string{".pem", ".key", ".p12", ".pfx", ".keystore", ".jks"}
+	// secretFileSamples are .env variants meant to be committed
+	secretFileSamples = []string{".example", ".sample", ".template", ".dist"}
+)
+
+// DefaultRedactionConfig enables the built-in rules and entropy detection
+func DefaultRedactionConfig() RedactionConfig {
+	return RedactionConfig{
+		Enabled:          true,
+		Rules:            []RedactionRule{},
+		EntropyThreshold: defaultEntropyThreshold,
+	}
+}
+
+// AddRedaction implements ContextBuilder.
+func (c *contextBuilderImpl) AddRedaction(config RedactionConfig) {
+	if !config.Enabled {
+		return
+	}
+	redactor, err := newRedactor(config)
+	if err != nil {
+		c.errors = append(c.errors, err)
+		return
+	}
+	c.redactor = redactor
+}
+
+func newRedactor(config RedactionConfig) (*redactor, error) {
+	rules := make([]compiledRule, 0, len(builtinRedactionRules)+len(config.Rules))
+	for _, rule := range slices.Concat(builtinRedactionRules, config.Rules) {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule %q: %v", rule.Name, err)
+		}
+		rules = append(rules, compiledRule{name: rule.Name, pattern: pattern})
+	}
+	return &redactor{
+		rules:            rules,
+		entropyThreshold: config.EntropyThreshold,
+		found:            make(map[Redaction]int),
+	}, nil
+}
+
+// redactDiff redacts each file section of a diff, hiding secret files entirely
+func (r *redactor) redactDiff(diff string) string {
+	sections := strings.SplitAfter(diff, "\ndiff --git ")
+	for i, section := range sections {
+		path := diffSectionPath(section, i == 0)
+		if isSecretFile(path) {
+			sections[i] = r.hideDiffSection(path, section)
+		} else {
+			sections[i] = r.redact(path, section)
+		}
+	}
+	return strings.Join(sections, "")
+}
+
+// redactFile redacts the content of a file, hiding secret files entirely
+func (r *redactor) redactFile(path, content string) string {
+	if isSecretFile(path) && strings.TrimSpace(content) != "" {
+		r.found[Redaction{Rule: secretFileRule, Path: path}]++
+		return placeholder(secretFileRule) + "\n"
+	}
+	return r.redact(path, content)
+}
+
+func (r *redactor) redact(path, text string) string {
+	for _, rule := range r.rules {
+		text = r.replace(path, rule.name, rule.pattern, text)
+	}
+	if r.entropyThreshold <= 0 {
+		return text
+	}
+	return entropyCandidate.ReplaceAllStringFunc(text, func(token string) string {
+		if placeholderRegex.MatchString(token) || !isLikelySecret(token, r.entropyThreshold) {
+			return token
+		}
+		r.found[Redaction{Rule: entropyRule, Path: path}]++
+		return placeholder(entropyRule)
+	})
+}
+
+// replace substitutes the matches of pattern, or only their "secret" group when it has one
+func (r *redactor) replace(path, name string, pattern *regexp.Regexp, text string) string {
+	group := pattern.SubexpIndex("secret")
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var result strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if group > 0 {
+			start, end = match[2*group], match[2*group+1]
+		}
+		if start < 0 || placeholderRegex.MatchString(text[start:end]) {
+			continue
+		}
+		result.WriteString(text[last:start])
+		result.WriteString(placeholder(name))
+		last = end
+		r.found[Redaction{Rule: name, Path: path}]++
+	}
+	result.WriteString(text[last:])
+	return result.String()
+}
+
+// hideDiffSection keeps the headers of a secret file diff and drops its lines
+func (r *redactor) hideDiffSection(path, section string) string {
+	hidden := hideDiffLines(section, placeholder(secretFileRule))
+	if hidden != section {
+		r.found[Redaction{Rule: secretFileRule, Path: path}]++
+	}
+	return hidden
+}
+
+// hideDiffLines replaces the changed lines of a diff section with a single placeholder line,
+// hunk headers are cut to their line ranges
+func hideDiffLines(section, placeholder string) string {
+	lines := strings.Split(section, "\n")
+	inHunk := false
+	hidden := false
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+			// the function context git appends to the header is a line of the file
+			if header := hunkHeaderPattern.FindString(line); header != "" {
+				line = header
+			}
+			kept = append(kept, line)
+		case !inHunk || line == "" || strings.HasPrefix(line, "diff --git "):
+			kept = append(kept, line)
+		case !hidden:
+			kept = append(kept, " "+placeholder)
+			hidden = true
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// report lists the redactions sorted by path and rule
+func (r *redactor) report() []Redaction {
+	redactions := make([]Redaction, 0, len(r.found))
+	for redaction, count := range r.found {
+		redaction.Count = count
+		redactions = append(redactions, redaction)
+	}
+	sort.Slice(redactions, func(i, j int) bool {
+		if redactions[i].Path != redactions[j].Path {
+			return redactions[i].Path < redactions[j].Path
+		}
+		return redactions[i].Rule < redactions[j].Rule
+	})
+	return redactions
+}
+
+func (r Redaction) String() string {
+	if r.Count > 1 {
+		return fmt.Sprintf("%d × %s in %s", r.Count, r.Rule, r.Path)
+	}
+	return fmt.Sprintf("%s in %s", r.Rule, r.Path)
+}
+
+// DescribeRedactions summarises redactions as "rule in path" items
+func DescribeRedactions(redactions []Redaction) string {
+	parts := make([]string, 0, len(redactions))
+	for _, redaction := range redactions {
+		parts = append(parts, redaction.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+func placeholder(rule string) string {
+	return "<redacted:" + rule + ">"
+}
+
+// diffSectionPath returns the path of a section split after "diff --git "
+func diffSectionPath(section string, first bool) string {
+	header, _, _ := strings.Cut(section, "\n")
+	if first {
+		header = strings.TrimPrefix(header, "diff --git ")
+	}
+	if index := strings.LastIndex(header, " b/"); index >= 0 {
+		return header[index+3:]
+	}
+	return ""
+}
+
+func isSecretFile(path string) bool {
+	if path == "" {
+		return false
+	}
+	name := filepath.Base(path)
+	if name == ".env" || strings.HasPrefix(name, ".env.") {
+		for _, sample := range secretFileSamples {
+			if strings.HasSuffix(name, sample) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, secretName := range secretFileNames {
+		if name == secretName {
+			return true
+		}
+	}
+	for _, ext := range secretFileExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLikelySecret accepts random looking tokens mixing letters and digits
+func isLikelySecret(token string, threshold float64) bool {
+	hasDigit := strings.ContainsAny(token, "0123456789")
+	hasLetter := strings.ContainsFunc(token, func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+	})
+	return hasDigit && hasLetter && shannonEntropy(token) >= threshold
+}
+
+func shannonEntropy(token string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range token {
+		counts[r]++
+	}
+	entropy := 0.0
+	length := float64(len(token))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,40 @@
+package project
+
+import (
+	"strings"
+	"testing"
+)
+
+const envDiff = `diff --git a/.env b/.env
+index 1111111..2222222 100644
+--- a/.env
++++ b/.env
+@@ -3,4 +3,4 @@ API_KEY=sk-live-SECRETVALUE123
+ DEBUG=false
+-DB_PASSWORD=hunter2
++DB_PASSWORD=correct-horse
+ PORT=8080
+`
+
+func TestRedactDiffHidesSecretFileHunkHeaders(t *testing.T) {
+	r, err := newRedactor(DefaultRedactionConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	redacted := r.redactDiff(envDiff)
+
+	for _, secret := range []string{"SECRETVALUE123", "hunter2", "correct-horse", "PORT"} {
+		if strings.Contains(redacted, secret) {
+			t.Errorf("redacted diff contains %q:\n%s", secret, redacted)
+		}
+	}
+	if !strings.Contains(redacted, "@@ -3,4 +3,4 @@\n") {
+		t.Errorf("hunk header was not kept as a line range:\n%s", redacted)
+	}
+	if !strings.Contains(redacted, placeholder(secretFileRule)) {
+		t.Errorf("redacted diff lacks the placeholder:\n%s", redacted)
+	}
+	if report := r.report(); len(report) != 1 || report[0].Path != ".env" {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
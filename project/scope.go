@@ -0,0 +1,260 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type (
+	// ScopeConfig maps paths to scopes and decides whether inferred scopes are enforced
+	ScopeConfig struct {
+		// Paths maps path patterns, as in the policy, to scopes and wins over detected units
+		Paths map[string]string `json:"paths"`
+		// Enforce replaces the scope of generated messages with the inferred one
+		Enforce bool `json:"enforce"`
+	}
+	// workspaceUnit is a module or package of a monorepo
+	workspaceUnit struct {
+		dir   string
+		scope string
+	}
+)
+
+var (
+	gradleIncludePattern = regexp.MustCompile(`['"]:?([\w.:-]+)['"]`)
+	cargoMembersPattern  = regexp.MustCompile(`(?s)members\s*=\s*\[([^\]]*)\]`)
+	quotedPattern        = regexp.MustCompile(`["']([^"']+)["']`)
+	invalidScopeChars    = regexp.MustCompile(`[^a-z0-9._/-]+`)
+)
+
+// AddScopes implements ContextBuilder.
+func (c *contextBuilderImpl) AddScopes(config ScopeConfig) {
+	c.scopeConfig = &config
+	c.units = c.workspaceUnits()
+}
+
+// workspaceUnits detects go modules, npm and pnpm workspaces, Cargo workspace members and Gradle subprojects
+func (c *contextBuilderImpl) workspaceUnits() []workspaceUnit {
+	units := make([]workspaceUnit, 0)
+	workspaces := make([]string, 0)
+	for _, file := range c.files {
+		dir := path.Dir(file)
+		switch path.Base(file) {
+		case "go.mod":
+			if dir != "." {
+				units = append(units, workspaceUnit{dir: dir, scope: path.Base(dir)})
+			}
+		case "package.json":
+			if dir == "." {
+				workspaces = append(workspaces, npmWorkspaces(c.read(file))...)
+			}
+		case "pnpm-workspace.yaml":
+			if dir == "." {
+				workspaces = append(workspaces, pnpmWorkspaces(c.read(file))...)
+			}
+		case "Cargo.toml":
+			if dir == "." {
+				workspaces = append(workspaces, cargoMembers(c.read(file))...)
+			}
+		case "settings.gradle", "settings.gradle.kts":
+			for _, project := range gradleProjects(c.read(file)) {
+				units = append(units, workspaceUnit{dir: path.Join(dir, project), scope: path.Base(project)})
+			}
+		}
+	}
+
+	for _, file := range c.files {
+		dir := path.Dir(file)
+		name := path.Base(file)
+		if dir == "." || (name != "package.json" && name != "Cargo.toml") || !matchesWorkspace(workspaces, dir) {
+			continue
+		}
+		scope := path.Base(dir)
+		if name == "package.json" {
+			if packageName := npmPackageName(c.read(file)); packageName != "" {
+				scope = packageName
+			}
+		}
+		units = append(units, workspaceUnit{dir: dir, scope: scope})
+	}
+	return units
+}
+
+// inferScopes returns the scopes of the staged files, the most changed first.
+// Files outside of any unit do not vote.
+func (c *contextBuilderImpl) inferScopes() []string {
+	counts := make(map[string]int)
+	for _, file := range c.changes.Files() {
+		if scope := c.scopeOf(file.Path); scope != "" {
+			counts[scope] += file.Added + file.Deleted + 1
+		}
+	}
+
+	scopes := make([]string, 0, len(counts))
+	for scope := range counts {
+		scopes = append(scopes, scope)
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		if counts[scopes[i]] != counts[scopes[j]] {
+			return counts[scopes[i]] > counts[scopes[j]]
+		}
+		return scopes[i] < scopes[j]
+	})
+	return scopes
+}
+
+// scopeOf prefers the configured paths, then the deepest unit containing the file
+func (c *contextBuilderImpl) scopeOf(file string) string {
+	patterns := make([]string, 0, len(c.scopeConfig.Paths))
+	for pattern := range c.scopeConfig.Paths {
+		patterns = append(patterns, pattern)
+	}
+	// longer patterns are more specific
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		if MatchPath(pattern, file) {
+			return normalizeScope(c.scopeConfig.Paths[pattern])
+		}
+	}
+
+	best := workspaceUnit{}
+	for _, unit := range c.units {
+		if strings.HasPrefix(file, unit.dir+"/") && len(unit.dir) > len(best.dir) {
+			best = unit
+		}
+	}
+	return normalizeScope(best.scope)
+}
+
+func (c *contextBuilderImpl) read(file string) []byte {
+	content, err := os.ReadFile(filepath.Join(c.dir, file))
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+// formatScopes tells the model which scope to use
+func formatScopes(scopes []string) string {
+	if len(scopes) == 1 {
+		return fmt.Sprintf("The staged files belong to the %q module, use %q as the scope\n", scopes[0], scopes[0])
+	}
+	return fmt.Sprintf("The staged files span the modules %s (most changed first), use the most affected one as the scope or list them separated by commas\n",
+		strings.Join(scopes, ", "))
+}
+
+func npmWorkspaces(content []byte) []string {
+	var manifest struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil || len(manifest.Workspaces) == 0 {
+		return nil
+	}
+	// workspaces is either a list of globs or an object with a packages list, as yarn allows
+	var globs []string
+	if err := json.Unmarshal(manifest.Workspaces, &globs); err == nil {
+		return globs
+	}
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(manifest.Workspaces, &object); err != nil {
+		return nil
+	}
+	return object.Packages
+}
+
+// npmPackageName returns the package name without its npm scope
+func npmPackageName(content []byte) string {
+	var manifest struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return ""
+	}
+	return path.Base(manifest.Name)
+}
+
+// pnpmWorkspaces reads the packages list of pnpm-workspace.yaml without a YAML parser
+func pnpmWorkspaces(content []byte) []string {
+	globs := make([]string, 0)
+	inPackages := false
+	for line := range strings.SplitSeq(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "packages:"):
+			inPackages = true
+		case inPackages && strings.HasPrefix(trimmed, "- "):
+			glob := strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "- ")), `"'`)
+			if !strings.HasPrefix(glob, "!") {
+				globs = append(globs, glob)
+			}
+		case trimmed != "" && !strings.HasPrefix(trimmed, "#"):
+			inPackages = false
+		}
+	}
+	return globs
+}
+
+func cargoMembers(content []byte) []string {
+	workspace := strings.Index(string(content), "[workspace]")
+	if workspace < 0 {
+		return nil
+	}
+	match := cargoMembersPattern.FindSubmatch(content[workspace:])
+	if match == nil {
+		return nil
+	}
+	members := make([]string, 0)
+	for _, quoted := range quotedPattern.FindAllSubmatch(match[1], -1) {
+		members = append(members, string(quoted[1]))
+	}
+	return members
+}
+
+// gradleProjects turns include(":lib:core") into lib/core
+func gradleProjects(content []byte) []string {
+	projects := make([]string, 0)
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "include") {
+			continue
+		}
+		for _, match := range gradleIncludePattern.FindAllStringSubmatch(line, -1) {
+			projects = append(projects, strings.ReplaceAll(match[1], ":", "/"))
+		}
+	}
+	return projects
+}
+
+// matchesWorkspace matches a directory against workspace globs such as packages/* or crates/**
+func matchesWorkspace(globs []string, dir string) bool {
+	for _, glob := range globs {
+		glob = strings.TrimSuffix(strings.TrimPrefix(glob, "./"), "/")
+		if prefix, ok := strings.CutSuffix(glob, "/**"); ok {
+			if strings.HasPrefix(dir, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if matched, _ := path.Match(glob, dir); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeScope makes directory and package names valid scopes
+func normalizeScope(scope string) string {
+	return strings.Trim(invalidScopeChars.ReplaceAllString(strings.ToLower(scope), "-"), "-")
+}
@@ -0,0 +1,226 @@
+package project
+
+import (
+	"fmt"
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/wert2all/ai-commit/changes"
+)
+
+const (
+	SymbolAdded    = "added"
+	SymbolRemoved  = "removed"
+	SymbolModified = "modified"
+)
+
+type (
+	// Analyzer extracts the public symbols a source file declares
+	Analyzer interface {
+		Extensions() []string
+		Symbols(source []byte) ([]Symbol, error)
+	}
+	Symbol struct {
+		// Kind is a language term such as func, method, type, interface or class
+		Kind      string
+		Name      string
+		Signature string
+		// Body changes without a signature change are never breaking
+		Body string
+		// Members are fields or methods by name with their signatures
+		Members map[string]string
+		// Extensible members may be added without breaking callers, unlike interface methods
+		Extensible bool
+	}
+	SymbolChange struct {
+		Path      string   `json:"path"`
+		Kind      string   `json:"kind"`
+		Name      string   `json:"name"`
+		Change    string   `json:"change"`
+		Signature string   `json:"signature"`
+		Details   []string `json:"details,omitempty"`
+		// Breaking flags signature changes and removals of public symbols
+		Breaking bool `json:"breaking"`
+	}
+)
+
+// analyzers is the registry of language analysers, looked up by file extension
+var analyzers = []Analyzer{
+	goAnalyzer{},
+	typeScriptAnalyzer,
+	pythonAnalyzer,
+	javaAnalyzer,
+	kotlinAnalyzer,
+	rustAnalyzer,
+	phpAnalyzer,
+	rubyAnalyzer,
+}
+
+// analyzerFor returns the analyser of a file, or nil when it declares no public symbols.
+// Go test files only build into tests, so their exported Test functions are not API.
+func analyzerFor(path string) Analyzer {
+	if strings.HasSuffix(path, "_test.go") {
+		return nil
+	}
+	ext := filepath.Ext(path)
+	for _, analyzer := range analyzers {
+		if slices.Contains(analyzer.Extensions(), ext) {
+			return analyzer
+		}
+	}
+	return nil
+}
+
+// AddSemanticChanges implements ContextBuilder.
+func (c *contextBuilderImpl) AddSemanticChanges() {
+	c.withSemantics = true
+}
+
+// semanticChanges analyses the staged files of the languages with an analyser
+func (c *contextBuilderImpl) semanticChanges() []SymbolChange {
+	result := make([]SymbolChange, 0)
+	for _, file := range c.changes.Files() {
+		analyzer := analyzerFor(file.Path)
+		if analyzer == nil || c.isExcluded(file.Path) {
+			continue
+		}
+		result = append(result, c.compareFile(analyzer, file)...)
+	}
+	return result
+}
+
+// compareFile compares the symbols of the HEAD and the staged version of a file
+func (c *contextBuilderImpl) compareFile(analyzer Analyzer, file changes.FileDiff) []SymbolChange {
+	before := map[string]Symbol{}
+	if file.Status != changes.FileAdded {
+		if source, ok := c.gitShow("HEAD:" + file.OldPath); ok {
+			symbols, err := analyzer.Symbols(source)
+			if err != nil {
+				return nil
+			}
+			before = symbolsByKey(symbols)
+		}
+	}
+	after := map[string]Symbol{}
+	if file.Status != changes.FileDeleted {
+		source, ok := c.gitShow(":" + file.Path)
+		if !ok {
+			return nil
+		}
+		symbols, err := analyzer.Symbols(source)
+		if err != nil {
+			return nil
+		}
+		after = symbolsByKey(symbols)
+	}
+	return compareSymbols(file.Path, before, after)
+}
+
+func (c *contextBuilderImpl) gitShow(object string) ([]byte, bool) {
+	cmd := exec.Command("git", "show", object)
+	cmd.Dir = c.dir
+	output, err := cmd.Output()
+	return output, err == nil
+}
+
+func symbolsByKey(symbols []Symbol) map[string]Symbol {
+	byKey := make(map[string]Symbol, len(symbols))
+	for _, symbol := range symbols {
+		byKey[symbol.Kind+" "+symbol.Name] = symbol
+	}
+	return byKey
+}
+
+func compareSymbols(path string, before, after map[string]Symbol) []SymbolChange {
+	keys := slices.Collect(maps.Keys(after))
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	// map order would make the context, and the cache key, differ between runs
+	slices.Sort(keys)
+
+	result := make([]SymbolChange, 0)
+	for _, key := range keys {
+		old, existed := before[key]
+		current, exists := after[key]
+		switch {
+		case !existed:
+			result = append(result, SymbolChange{Path: path, Kind: current.Kind, Name: current.Name, Change: SymbolAdded, Signature: current.Signature})
+		case !exists:
+			result = append(result, SymbolChange{Path: path, Kind: old.Kind, Name: old.Name, Change: SymbolRemoved, Signature: old.Signature, Breaking: true})
+		default:
+			if change, ok := compareSymbol(path, old, current); ok {
+				result = append(result, change)
+			}
+		}
+	}
+	return result
+}
+
+func compareSymbol(path string, old, current Symbol) (SymbolChange, bool) {
+	change := SymbolChange{Path: path, Kind: current.Kind, Name: current.Name, Change: SymbolModified, Signature: current.Signature}
+	if old.Signature != current.Signature {
+		change.Details = append(change.Details, "signature changed, was: "+old.Signature)
+		change.Breaking = true
+	}
+
+	names := slices.Sorted(maps.Keys(current.Members))
+	for name := range old.Members {
+		if _, ok := current.Members[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		oldMember, existed := old.Members[name]
+		member, exists := current.Members[name]
+		switch {
+		case !existed:
+			change.Details = append(change.Details, "added "+member)
+			change.Breaking = change.Breaking || !current.Extensible
+		case !exists:
+			change.Details = append(change.Details, "removed "+oldMember)
+			change.Breaking = true
+		case oldMember != member:
+			change.Details = append(change.Details, fmt.Sprintf("changed %s to %s", oldMember, member))
+			change.Breaking = true
+		}
+	}
+
+	if len(change.Details) == 0 && old.Body != current.Body {
+		change.Details = append(change.Details, "implementation changed")
+	}
+	return change, len(change.Details) > 0
+}
+
+func (s SymbolChange) String() string {
+	marker := map[string]string{SymbolAdded: "+", SymbolRemoved: "-", SymbolModified: "~"}[s.Change]
+	line := fmt.Sprintf("%s %s", marker, s.Signature)
+	if len(s.Details) > 0 {
+		line += " (" + strings.Join(s.Details, "; ") + ")"
+	}
+	if s.Breaking {
+		line += " [potentially breaking]"
+	}
+	return line
+}
+
+// formatSemanticChanges lists the changes grouped by file
+func formatSemanticChanges(symbolChanges []SymbolChange) string {
+	var result strings.Builder
+	path := ""
+	for _, change := range symbolChanges {
+		if change.Path != path {
+			path = change.Path
+			result.WriteString(path + ":\n")
+		}
+		result.WriteString("  " + change.String() + "\n")
+	}
+	return result.String()
+}
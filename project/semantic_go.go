@@ -0,0 +1,132 @@
+package project
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// goAnalyzer reports exported functions, methods and types of Go files
+type goAnalyzer struct{}
+
+func (goAnalyzer) Extensions() []string {
+	return []string{".go"}
+}
+
+func (goAnalyzer) Symbols(source []byte) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make([]Symbol, 0)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if symbol, ok := goFuncSymbol(fset, decl); ok {
+				symbols = append(symbols, symbol)
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				if symbol, ok := goTypeSymbol(fset, spec.(*ast.TypeSpec)); ok {
+					symbols = append(symbols, symbol)
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+func goFuncSymbol(fset *token.FileSet, decl *ast.FuncDecl) (Symbol, bool) {
+	if !decl.Name.IsExported() {
+		return Symbol{}, false
+	}
+
+	symbol := Symbol{Kind: "func", Name: decl.Name.Name}
+	signature := "func "
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		receiver := goNode(fset, decl.Recv.List[0].Type)
+		if !ast.IsExported(strings.TrimLeft(strings.SplitN(receiver, "[", 2)[0], "*")) {
+			return Symbol{}, false
+		}
+		symbol.Kind = "method"
+		symbol.Name = "(" + receiver + ")." + decl.Name.Name
+		signature += "(" + receiver + ") "
+	}
+	symbol.Signature = signature + decl.Name.Name + strings.TrimPrefix(goNode(fset, decl.Type), "func")
+	if decl.Body != nil {
+		symbol.Body = goNode(fset, decl.Body)
+	}
+	return symbol, true
+}
+
+func goTypeSymbol(fset *token.FileSet, spec *ast.TypeSpec) (Symbol, bool) {
+	if !spec.Name.IsExported() {
+		return Symbol{}, false
+	}
+
+	symbol := Symbol{Kind: "type", Name: spec.Name.Name}
+	typeParams := ""
+	if spec.TypeParams != nil {
+		typeParams = goNode(fset, spec.TypeParams)
+	}
+	assign := " "
+	if spec.Assign.IsValid() {
+		assign = " = "
+	}
+
+	switch typ := spec.Type.(type) {
+	case *ast.StructType:
+		symbol.Signature = "type " + spec.Name.Name + typeParams + " struct"
+		symbol.Members = goFields(fset, typ.Fields)
+		symbol.Extensible = true
+	case *ast.InterfaceType:
+		symbol.Kind = "interface"
+		symbol.Signature = "type " + spec.Name.Name + typeParams + " interface"
+		symbol.Members = goFields(fset, typ.Methods)
+	default:
+		symbol.Signature = "type " + spec.Name.Name + typeParams + assign + goNode(fset, spec.Type)
+	}
+	return symbol, true
+}
+
+// goFields returns exported fields and interface methods, embedded ones keyed by type
+func goFields(fset *token.FileSet, fields *ast.FieldList) map[string]string {
+	members := make(map[string]string)
+	if fields == nil {
+		return members
+	}
+	for _, field := range fields.List {
+		typ := goNode(fset, field.Type)
+		if len(field.Names) == 0 {
+			members[typ] = typ
+			continue
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			if strings.HasPrefix(typ, "func(") {
+				members[name.Name] = name.Name + strings.TrimPrefix(typ, "func")
+			} else {
+				members[name.Name] = name.Name + " " + typ
+			}
+		}
+	}
+	return members
+}
+
+func goNode(fset *token.FileSet, node ast.Node) string {
+	var buffer bytes.Buffer
+	if err := printer.Fprint(&buffer, fset, node); err != nil {
+		return ""
+	}
+	return buffer.String()
+}
@@ -0,0 +1,118 @@
+package project
+
+import (
+	"slices"
+	"testing"
+)
+
+// symbolChanges compares two versions of a file with the analyser of its path
+func symbolChanges(t *testing.T, path, before, after string) []string {
+	t.Helper()
+	analyzer := analyzerFor(path)
+	if analyzer == nil {
+		return nil
+	}
+	parse := func(source string) map[string]Symbol {
+		if source == "" {
+			return map[string]Symbol{}
+		}
+		symbols, err := analyzer.Symbols([]byte(source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return symbolsByKey(symbols)
+	}
+	result := make([]string, 0)
+	for _, change := range compareSymbols(path, parse(before), parse(after)) {
+		result = append(result, change.String())
+	}
+	return result
+}
+
+func TestGoAnalyzer(t *testing.T) {
+	tests := []struct {
+		name, path, before, after string
+		want                      []string
+	}{
+		{
+			name:   "added func",
+			path:   "calc.go",
+			before: "package calc\n",
+			after:  "package calc\n\nfunc Add(a, b int) int { return a + b }\n\nfunc sub(a, b int) int { return a - b }\n",
+			want:   []string{"+ func Add(a, b int) int"},
+		},
+		{
+			name:   "removed func",
+			path:   "calc.go",
+			before: "package calc\n\nfunc Add(a, b int) int { return a + b }\n",
+			after:  "package calc\n",
+			want:   []string{"- func Add(a, b int) int [potentially breaking]"},
+		},
+		{
+			name:   "changed signature",
+			path:   "calc.go",
+			before: "package calc\n\nfunc Add(a, b int) int { return a + b }\n",
+			after:  "package calc\n\nfunc Add(a, b float64) float64 { return a + b }\n",
+			want:   []string{"~ func Add(a, b float64) float64 (signature changed, was: func Add(a, b int) int) [potentially breaking]"},
+		},
+		{
+			name:   "changed body",
+			path:   "calc.go",
+			before: "package calc\n\nfunc Add(a, b int) int { return a + b }\n",
+			after:  "package calc\n\nfunc Add(a, b int) int { return b + a }\n",
+			want:   []string{"~ func Add(a, b int) int (implementation changed)"},
+		},
+		{
+			name:   "changed struct field",
+			path:   "user.go",
+			before: "package user\n\ntype User struct {\n\tName string\n\tAge  int\n\tid   int\n}\n",
+			after:  "package user\n\ntype User struct {\n\tName  string\n\tAge   int64\n\tEmail string\n\tid    string\n}\n",
+			want:   []string{"~ type User struct (changed Age int to Age int64; added Email string) [potentially breaking]"},
+		},
+		{
+			name:   "added struct field",
+			path:   "user.go",
+			before: "package user\n\ntype User struct {\n\tName string\n}\n",
+			after:  "package user\n\ntype User struct {\n\tName  string\n\tEmail string\n}\n",
+			want:   []string{"~ type User struct (added Email string)"},
+		},
+		{
+			name:   "changed interface method",
+			path:   "store.go",
+			before: "package store\n\ntype Store interface {\n\tGet(key string) string\n}\n",
+			after:  "package store\n\ntype Store interface {\n\tGet(key string) (string, error)\n\tPut(key, value string)\n}\n",
+			want:   []string{"~ type Store interface (changed Get(key string) string to Get(key string) (string, error); added Put(key, value string)) [potentially breaking]"},
+		},
+		{
+			name:   "changed method receiver",
+			path:   "user.go",
+			before: "package user\n\ntype User struct{}\n\nfunc (u User) Name() string { return \"\" }\n",
+			after:  "package user\n\ntype User struct{}\n\nfunc (u *User) Name() string { return \"\" }\n",
+			want: []string{
+				"+ func (*User) Name() string",
+				"- func (User) Name() string [potentially breaking]",
+			},
+		},
+		{
+			name:   "method of an unexported type",
+			path:   "user.go",
+			before: "package user\n",
+			after:  "package user\n\ntype user struct{}\n\nfunc (u user) Name() string { return \"\" }\n",
+			want:   []string{},
+		},
+		{
+			name:   "test funcs",
+			path:   "calc_test.go",
+			before: "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {}\n",
+			after:  "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T, extra int) {}\n\nfunc TestSub(t *testing.T) {}\n",
+			want:   nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := symbolChanges(t, test.path, test.before, test.after); !slices.Equal(got, test.want) {
+				t.Errorf("changes =\n%q\nwant\n%q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package project
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type (
+	// lineAnalyzer finds declarations line by line with regular expressions.
+	// It does not parse, so it trades precision for supporting many languages without grammars.
+	lineAnalyzer struct {
+		extensions []string
+		patterns   []symbolPattern
+		// private reports names that are not part of the public API
+		private func(name string) bool
+		// indented languages end blocks by indentation, the others by braces
+		indented bool
+	}
+	// openContainer is a container declaration whose block has not ended yet
+	openContainer struct {
+		name string
+		// level is the brace depth or the indentation of the declaration
+		level int
+		// opened is set once the block of a braced container has started
+		opened bool
+	}
+	symbolPattern struct {
+		// kind of the declared symbol, empty for containers that are not symbols themselves
+		kind  string
+		regex *regexp.Regexp
+		// container declarations name the members declared after them
+		container bool
+		member    bool
+	}
+)
+
+var (
+	typeScriptAnalyzer = lineAnalyzer{
+		extensions: []string{".ts", ".tsx", ".mts", ".cts", ".js", ".jsx", ".mjs", ".cjs"},
+		patterns: []symbolPattern{
+			{kind: "function", regex: regexp.MustCompile(`^export\s+(?:default\s+)?(?:declare\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>\w+)\s*(?:<[^>]*>)?\s*\([^)]*\)(?:\s*:\s*[^{]+)?`)},
+			{kind: "function", regex: regexp.MustCompile(`^export\s+const\s+(?P<name>\w+)\s*(?::\s*[^=]+)?=\s*(?:async\s*)?(?:<[^>]*>)?\([^)]*\)(?:\s*:\s*[^=]+)?\s*=>`)},
+			{kind: "class", regex: regexp.MustCompile(`^export\s+(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?class\s+(?P<name>\w+)(?:<[^>]*>)?(?:\s+(?:extends|implements)\s+[^{]+)?`), container: true},
+			{kind: "interface", regex: regexp.MustCompile(`^export\s+(?:declare\s+)?interface\s+(?P<name>\w+)(?:<[^>]*>)?(?:\s+extends\s+[^{]+)?`)},
+			{kind: "type", regex: regexp.MustCompile(`^export\s+(?:declare\s+)?type\s+(?P<name>\w+)(?:<[^>]*>)?\s*=.*`)},
+			{kind: "method", regex: regexp.MustCompile(`^\s+(?:public\s+)?(?:static\s+)?(?:async\s+)?(?P<name>[a-zA-Z]\w*)\s*(?:<[^>]*>)?\s*\([^)]*\)(?:\s*:\s*[^{]+)?\s*\{`), member: true},
+		},
+		private: func(name string) bool {
+			return strings.HasPrefix(name, "_")
+		},
+	}
+	pythonAnalyzer = lineAnalyzer{
+		extensions: []string{".py", ".pyi"},
+		patterns: []symbolPattern{
+			{kind: "function", regex: regexp.MustCompile(`^(?:async\s+)?def\s+(?P<name>\w+)\s*\(.*\)(?:\s*->\s*[^:]+)?`)},
+			{kind: "class", regex: regexp.MustCompile(`^class\s+(?P<name>\w+)(?:\([^)]*\))?`), container: true},
+			{kind: "method", regex: regexp.MustCompile(`^\s+(?:async\s+)?def\s+(?P<name>\w+)\s*\(.*\)(?:\s*->\s*[^:]+)?`), member: true},
+		},
+		private: func(name string) bool {
+			return strings.HasPrefix(name, "_") && !(strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__"))
+		},
+		indented: true,
+	}
+	javaAnalyzer = lineAnalyzer{
+		extensions: []string{".java"},
+		patterns: []symbolPattern{
+			{kind: "class", regex: regexp.MustCompile(`^\s*public\s+(?:(?:abstract|final|static|sealed|non-sealed)\s+)*(?:class|interface|enum|record|@interface)\s+(?P<name>\w+)(?:<[^>]*>)?(?:\([^)]*\))?(?:\s+(?:extends|implements|permits)\s+[^{]+)?`), container: true},
+			{kind: "method", regex: regexp.MustCompile(`^\s+(?:public|protected)\s+(?:(?:static|final|abstract|synchronized|default|native)\s+)*(?:<[^>]+>\s+)?(?:[\w.]+(?:<[^()]*>)?(?:\[\])*\s+)?(?P<name>\w+)\s*\([^)]*\)(?:\s*throws\s+[\w., ]+)?`), member: true},
+		},
+	}
+	kotlinAnalyzer = lineAnalyzer{
+		extensions: []string{".kt", ".kts"},
+		patterns: []symbolPattern{
+			{kind: "class", regex: regexp.MustCompile(`^\s*(?:(?:public|open|abstract|final|data|sealed|enum|inner|value|annotation|expect|actual)\s+)*(?:class|interface|object)\s+(?P<name>\w+)(?:<[^>]*>)?(?:\s*(?:\([^)]*\))?)`), container: true},
+			{kind: "function", regex: regexp.MustCompile(`^(?:(?:public|inline|suspend|operator|infix|tailrec|expect|actual)\s+)*fun\s+(?:<[^>]+>\s+)?(?:[\w.<>]+\.)?(?P<name>\w+)\s*\([^)]*\)(?:\s*:\s*[\w<>?, .]+)?`)},
+			{kind: "method", regex: regexp.MustCompile(`^\s+(?:(?:public|open|abstract|final|override|inline|suspend|operator|infix|tailrec)\s+)*fun\s+(?:<[^>]+>\s+)?(?:[\w.<>]+\.)?(?P<name>\w+)\s*\([^)]*\)(?:\s*:\s*[\w<>?, .]+)?`), member: true},
+		},
+	}
+	rustAnalyzer = lineAnalyzer{
+		extensions: []string{".rs"},
+		patterns: []symbolPattern{
+			{kind: "function", regex: regexp.MustCompile(`^pub(?:\([^)]*\))?\s+(?:(?:const|async|unsafe)\s+)*(?:extern\s+"[^"]*"\s+)?fn\s+(?P<name>\w+)\s*(?:<[^>]*>)?\s*\([^)]*\)(?:\s*->\s*[^{;]+)?`)},
+			{kind: "type", regex: regexp.MustCompile(`^pub(?:\([^)]*\))?\s+(?:struct|enum|union|type)\s+(?P<name>\w+)(?:<[^>]*>)?(?:\s*=\s*[^;]+)?`)},
+			{kind: "trait", regex: regexp.MustCompile(`^pub(?:\([^)]*\))?\s+(?:unsafe\s+)?trait\s+(?P<name>\w+)(?:<[^>]*>)?(?:\s*:\s*[^{]+)?`), container: true},
+			{regex: regexp.MustCompile(`^impl(?:<[^>]*>)?\s+(?:[\w:<>, ]+\s+for\s+)?(?P<name>\w+)`), container: true},
+			{kind: "method", regex: regexp.MustCompile(`^\s+pub(?:\([^)]*\))?\s+(?:(?:const|async|unsafe)\s+)*fn\s+(?P<name>\w+)\s*(?:<[^>]*>)?\s*\([^)]*\)(?:\s*->\s*[^{;]+)?`), member: true},
+		},
+	}
+	phpAnalyzer = lineAnalyzer{
+		extensions: []string{".php"},
+		patterns: []symbolPattern{
+			{kind: "function", regex: regexp.MustCompile(`^function\s+&?\s*(?P<name>\w+)\s*\([^)]*\)(?:\s*:\s*\??[\w\\|]+)?`)},
+			{kind: "class", regex: regexp.MustCompile(`^\s*(?:(?:abstract|final|readonly)\s+)*(?:class|interface|trait|enum)\s+(?P<name>\w+)(?:\s+(?:extends|implements)\s+[^{]+)?`), container: true},
+			{kind: "method", regex: regexp.MustCompile(`^\s+(?:(?:public|static|final|abstract)\s+)*function\s+&?\s*(?P<name>\w+)\s*\([^)]*\)(?:\s*:\s*\??[\w\\|]+)?`), member: true},
+		},
+	}
+	rubyAnalyzer = lineAnalyzer{
+		extensions: []string{".rb"},
+		patterns: []symbolPattern{
+			{kind: "class", regex: regexp.MustCompile(`^\s*(?:class|module)\s+(?P<name>[\w:]+)(?:\s*<\s*[\w:]+)?`), container: true},
+			{kind: "function", regex: regexp.MustCompile(`^def\s+(?:self\.)?(?P<name>\w+[?!=]?)(?:\s*\(.*\))?`)},
+			{kind: "method", regex: regexp.MustCompile(`^\s+def\s+(?:self\.)?(?P<name>\w+[?!=]?)(?:\s*\(.*\))?`), member: true},
+		},
+		private: func(name string) bool {
+			return strings.HasPrefix(name, "_")
+		},
+		// blocks end with the end keyword, which is indented like the declaration
+		indented: true,
+	}
+)
+
+// keywords look like method declarations to the line patterns of C-like languages
+var keywords = []string{"if", "for", "while", "switch", "catch", "return", "else", "synchronized", "function"}
+
+func (a lineAnalyzer) Extensions() []string {
+	return a.extensions
+}
+
+// Symbols implements Analyzer. The body of a symbol is everything up to the next declaration.
+// Members belong to the innermost container whose block, by braces or indentation, they are in.
+func (a lineAnalyzer) Symbols(source []byte) ([]Symbol, error) {
+	symbols := make([]Symbol, 0)
+	containers := make([]openContainer, 0)
+	depth := 0
+	var current *Symbol
+	var body strings.Builder
+
+	flush := func() {
+		if current != nil {
+			current.Body = body.String()
+			symbols = append(symbols, *current)
+		}
+		current = nil
+		body.Reset()
+	}
+
+	for line := range strings.SplitSeq(string(source), "\n") {
+		level := depth
+		if a.indented {
+			level = len(line) - len(strings.TrimLeft(line, " \t"))
+			// a line indented like a container, or less, ends its block
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				for len(containers) > 0 && containers[len(containers)-1].level >= level {
+					containers = containers[:len(containers)-1]
+				}
+			}
+		}
+
+		pattern, match := a.match(line)
+		if pattern == nil {
+			body.WriteString(line + "\n")
+		} else {
+			flush()
+			// a container without a block, such as a Kotlin class without a body, ends at the next declaration
+			for !a.indented && len(containers) > 0 && !containers[len(containers)-1].opened && containers[len(containers)-1].level >= level {
+				containers = containers[:len(containers)-1]
+			}
+			current = a.symbol(pattern, match, containers)
+			switch {
+			case pattern.container:
+				containers = append(containers, openContainer{name: match[pattern.regex.SubexpIndex("name")], level: level})
+			case a.indented:
+				// an unnamed block, so that functions nested in a function are not members
+				containers = append(containers, openContainer{level: level})
+			}
+		}
+
+		if !a.indented {
+			depth = braces(line, depth, &containers)
+		}
+	}
+	flush()
+	return symbols, nil
+}
+
+// symbol returns the symbol a declaration line declares in the innermost open container,
+// or nil for private names, containers that are not symbols and members outside containers
+func (a lineAnalyzer) symbol(pattern *symbolPattern, match []string, containers []openContainer) *Symbol {
+	name := match[pattern.regex.SubexpIndex("name")]
+	if pattern.kind == "" || (a.private != nil && a.private(name)) {
+		return nil
+	}
+
+	signature := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(match[0]), "{:"))
+	symbol := Symbol{Kind: pattern.kind, Name: name, Signature: signature}
+	if pattern.member {
+		if len(containers) == 0 {
+			return nil
+		}
+		container := containers[len(containers)-1]
+		if container.name == "" || (!a.indented && !container.opened) {
+			return nil
+		}
+		symbol.Name = container.name + "." + name
+		symbol.Signature = container.name + ": " + signature
+	}
+	return &symbol
+}
+
+// braces follows the brace depth through a line and closes the containers whose block ended.
+// Braces in double-quoted and template strings and after // comments are not counted.
+func braces(line string, depth int, containers *[]openContainer) int {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote && (i == 0 || line[i-1] != '\\') {
+				quote = 0
+			}
+		case r == '"' || r == '`':
+			quote = r
+		case r == '/' && strings.HasPrefix(line[i:], "//"):
+			return depth
+		case r == '{':
+			depth++
+			if n := len(*containers); n > 0 && depth > (*containers)[n-1].level {
+				(*containers)[n-1].opened = true
+			}
+		case r == '}':
+			depth--
+			for n := len(*containers); n > 0 && (*containers)[n-1].opened && depth <= (*containers)[n-1].level; n-- {
+				*containers = (*containers)[:n-1]
+			}
+		}
+	}
+	return depth
+}
+
+func (a lineAnalyzer) match(line string) (*symbolPattern, []string) {
+	for i := range a.patterns {
+		match := a.patterns[i].regex.FindStringSubmatch(line)
+		if match != nil && !slices.Contains(keywords, match[a.patterns[i].regex.SubexpIndex("name")]) {
+			return &a.patterns[i], match
+		}
+	}
+	return nil, nil
+}
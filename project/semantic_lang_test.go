@@ -0,0 +1,270 @@
+package project
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLineAnalyzers(t *testing.T) {
+	tests := []struct {
+		name, path, source string
+		want               []string
+	}{
+		{
+			name: "typescript",
+			path: "src/user.ts",
+			source: `export class User {
+  constructor(private name: string) {}
+
+  greet(other: string): string {
+    if (other) {
+      return "hi {" + other;
+    }
+    return "hi";
+  }
+
+  _secret() {
+    return 1;
+  }
+}
+
+const helpers = {
+  format(value: string) {
+    return value;
+  },
+};
+
+export function greetAll(users: User[]): void {
+  users.forEach((user) => {
+    user.greet("x");
+  });
+}
+
+class Internal {
+  run() {
+    return 0;
+  }
+}
+
+export interface Named {
+  name: string;
+}
+export type Id = string | number;
+export const toId = (value: string): Id => value;
+`,
+			want: []string{
+				"class User: export class User",
+				"method User.constructor: User: constructor(private name: string)",
+				"method User.greet: User: greet(other: string): string",
+				"function greetAll: export function greetAll(users: User[]): void",
+				"interface Named: export interface Named",
+				"type Id: export type Id = string | number;",
+				"function toId: export const toId = (value: string): Id =>",
+			},
+		},
+		{
+			name: "python",
+			path: "app/user.py",
+			source: `class User(Base):
+    def __init__(self, name):
+        self.name = name
+
+    # a comment at the class level
+    def greet(self, other) -> str:
+        def inner():
+            pass
+        return "hi"
+
+    def _secret(self):
+        pass
+
+
+def greet_all(users):
+    for user in users:
+        user.greet("x")
+
+
+async def fetch(url: str) -> bytes:
+    pass
+`,
+			want: []string{
+				"class User: class User(Base)",
+				"method User.__init__: User: def __init__(self, name)",
+				"method User.greet: User: def greet(self, other) -> str",
+				"function greet_all: def greet_all(users)",
+				"function fetch: async def fetch(url: str) -> bytes",
+			},
+		},
+		{
+			name: "java",
+			path: "src/main/java/User.java",
+			source: `package app;
+
+public class User
+{
+    public String greet(String other) {
+        if (other != null) {
+            return "hi";
+        }
+        return "";
+    }
+
+    private void secret() {}
+
+    public static class Builder {
+        public User build() {
+            return new User();
+        }
+    }
+
+    protected int age() {
+        return 0;
+    }
+}
+`,
+			want: []string{
+				"class User: public class User",
+				"method User.greet: User: public String greet(String other)",
+				"class Builder: public static class Builder",
+				"method Builder.build: Builder: public User build()",
+				"method User.age: User: protected int age()",
+			},
+		},
+		{
+			name: "kotlin",
+			path: "src/User.kt",
+			source: `data class Point(val x: Int, val y: Int)
+
+class User(val name: String) {
+    fun greet(other: String): String {
+        return "hi"
+    }
+}
+
+fun greetAll(users: List<User>) {
+    users.forEach { it.greet("x") }
+}
+`,
+			want: []string{
+				"class Point: data class Point(val x: Int, val y: Int)",
+				"class User: class User(val name: String)",
+				"method User.greet: User: fun greet(other: String): String",
+				"function greetAll: fun greetAll(users: List<User>)",
+			},
+		},
+		{
+			name: "rust",
+			path: "src/user.rs",
+			source: `pub struct User {
+    name: String,
+}
+
+impl User {
+    pub fn new(name: &str) -> Self {
+        User { name: name.to_string() }
+    }
+
+    fn secret(&self) {}
+
+    pub fn name<'a>(&'a self) -> &'a str {
+        &self.name
+    }
+}
+
+pub trait Greet {
+    fn greet(&self) -> String;
+}
+
+pub fn greet_all(users: &[User]) {
+    for user in users {
+        user.name();
+    }
+}
+`,
+			want: []string{
+				"type User: pub struct User",
+				"method User.new: User: pub fn new(name: &str) -> Self",
+				"method User.name: User: pub fn name<'a>(&'a self) -> &'a str",
+				"trait Greet: pub trait Greet",
+				"function greet_all: pub fn greet_all(users: &[User])",
+			},
+		},
+		{
+			name: "php",
+			path: "src/User.php",
+			source: `<?php
+
+final class User
+{
+    public function greet(string $other): string
+    {
+        if ($other) {
+            return "hi {$other}";
+        }
+        return '';
+    }
+
+    private function secret() {}
+}
+
+function greet_all(array $users): void
+{
+    foreach ($users as $user) {
+        $user->greet('x');
+    }
+}
+`,
+			want: []string{
+				"class User: final class User",
+				"method User.greet: User: public function greet(string $other): string",
+				"function greet_all: function greet_all(array $users): void",
+			},
+		},
+		{
+			name: "ruby",
+			path: "lib/user.rb",
+			source: `module Accounts
+  class User < Base
+    def greet(other)
+      "hi"
+    end
+
+    def self.build(name)
+      new(name)
+    end
+  end
+
+  def helper
+  end
+end
+
+def greet_all(users)
+  users.each { |user| user.greet("x") }
+end
+`,
+			want: []string{
+				"class Accounts: module Accounts",
+				"class User: class User < Base",
+				"method User.greet: User: def greet(other)",
+				"method User.build: User: def self.build(name)",
+				"method Accounts.helper: Accounts: def helper",
+				"function greet_all: def greet_all(users)",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			symbols, err := analyzerFor(test.path).Symbols([]byte(test.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(symbols))
+			for _, symbol := range symbols {
+				got = append(got, symbol.Kind+" "+symbol.Name+": "+symbol.Signature)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("symbols =\n%q\nwant\n%q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/commit"
+	"github.com/wert2all/ai-commit/ledger"
+	"github.com/wert2all/ai-commit/message"
+	"github.com/wert2all/ai-commit/project"
+)
+
+// jsonOutput is set by --output json, errors are then reported as JSON too
+var jsonOutput bool
+
+type (
+	jsonMessage struct {
+		message.Message
+		Text       string              `json:"text"`
+		Valid      bool                `json:"valid"`
+		Violations []message.Violation `json:"violations"`
+	}
+	jsonUsage struct {
+		ai.Usage
+		TotalTokens int      `json:"total_tokens"`
+		CostUSD     *float64 `json:"cost_usd"`
+	}
+	jsonReport struct {
+		Message    jsonMessage         `json:"message"`
+		Provider   ai.ProviderInfo     `json:"provider"`
+		Usage      jsonUsage           `json:"usage"`
+		LatencyMS  int64               `json:"latency_ms"`
+		Candidates []jsonMessage       `json:"candidates"`
+		Files      []changes.FileDiff  `json:"files"`
+		Committed  bool                `json:"committed"`
+		Cached     bool                `json:"cached"`
+		Redactions []project.Redaction `json:"redactions"`
+		Failures   []string            `json:"failures"`
+		Scopes     []string            `json:"scopes"`
+	}
+	jsonError struct {
+		Error struct {
+			Code     string `json:"code"`
+			ExitCode int    `json:"exit_code"`
+			Message  string `json:"message"`
+		} `json:"error"`
+	}
+)
+
+// errorCodes are the stable identifiers of exit codes in JSON output
+var errorCodes = map[int]string{
+	exitError:      "error",
+	exitConfig:     "invalid_config",
+	exitNoChanges:  "no_changes",
+	exitProvider:   "provider_error",
+	exitValidation: "validation_failed",
+	exitCancelled:  "cancelled",
+}
+
+// runJSON generates a message and prints everything known about it as one JSON object
+func runJSON(config ai.Config) error {
+	generated, err := generate(config, "")
+	if err != nil {
+		return err
+	}
+
+	report := jsonReport{
+		Provider:   generated.providerInfo,
+		Usage:      jsonUsage{Usage: generated.usage, TotalTokens: generated.usage.Total()},
+		LatencyMS:  generated.latency.Milliseconds(),
+		Candidates: make([]jsonMessage, 0, len(generated.candidates)),
+		Files:      generated.context.Changes.Files(),
+		Cached:     generated.cached,
+		Redactions: generated.context.Redactions,
+		Failures:   generated.failures,
+		Scopes:     generated.context.Scopes,
+	}
+	if generated.costKnown {
+		report.Usage.CostUSD = &generated.cost
+	}
+	for _, candidate := range generated.candidates {
+		report.Candidates = append(report.Candidates, newJSONMessage(config, candidate))
+	}
+
+	commitMsg, violations := firstValid(config, generated.candidates)
+	report.Message = newJSONMessage(config, commitMsg)
+
+	if config.Options.WithCommit && config.Options.Yes && len(violations) == 0 {
+		if err := commit.Commit(commitMsg.String(), config.Directory); err != nil {
+			recordRun(config, generated, ledger.OutcomeRejected, commitMsg.String())
+			return err
+		}
+		report.Committed = true
+	}
+	outcome := ledger.OutcomeGenerated
+	if report.Committed {
+		outcome = ledger.OutcomeAccepted
+	}
+	recordRun(config, generated, outcome, commitMsg.String())
+
+	if err := printJSON(report); err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		os.Exit(exitValidation)
+	}
+	return nil
+}
+
+func newJSONMessage(config ai.Config, msg message.Message) jsonMessage {
+	violations := config.File.Convention.Validate(msg.String())
+	return jsonMessage{
+		Message:    msg,
+		Text:       msg.String(),
+		Valid:      len(violations) == 0,
+		Violations: violations,
+	}
+}
+
+func printJSONError(err error) {
+	var report jsonError
+	report.Error.ExitCode = exitCode(err)
+	report.Error.Code = errorCodes[report.Error.ExitCode]
+	report.Error.Message = err.Error()
+	if printErr := printJSON(report); printErr != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func printJSON(value any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/commit"
+	"github.com/wert2all/ai-commit/history"
+	"github.com/wert2all/ai-commit/ledger"
+	"github.com/wert2all/ai-commit/message"
+	"github.com/wert2all/ai-commit/project"
+	"github.com/wert2all/ai-commit/ui"
+)
+
+const rewordUsage = "usage: ai-commit reword [<base>[..HEAD]] [--body] [--force] [--yes] [--without-commit]"
+
+type (
+	jsonRewordReport struct {
+		Base      string               `json:"base"`
+		Commits   []jsonRewordedCommit `json:"commits"`
+		Provider  ai.ProviderInfo      `json:"provider"`
+		Rewritten bool                 `json:"rewritten"`
+		// Head is the new tip of the branch once rewritten
+		Head string `json:"head,omitempty"`
+	}
+	jsonRewordedCommit struct {
+		Hash string `json:"hash"`
+		Old  string `json:"old"`
+		New  string `json:"new"`
+		// Violations of the commit convention by the new message
+		Violations []message.Violation `json:"violations,omitempty"`
+	}
+)
+
+// runReword generates a new message for every commit of a range from its own changes
+// and rewrites the history of the current branch on confirmation
+func runReword(config ai.Config) error {
+	if len(config.Args) > 2 {
+		return errors.New(rewordUsage)
+	}
+	base, head, err := revisionRange(config)
+	if err != nil {
+		return err
+	}
+	commits, err := history.List(config.Directory, base, head)
+	if err != nil {
+		return withExitCode(exitNoChanges, err)
+	}
+	// refuse before asking the provider for anything
+	if !config.Options.Force {
+		if err := history.CheckProtected(config.Directory, commits, config.File.ProtectedBranches); err != nil {
+			return err
+		}
+	}
+
+	provider, err := ai.NewProvider(config)
+	if err != nil {
+		return withExitCode(exitProvider, err)
+	}
+	report := jsonRewordReport{Base: base, Commits: make([]jsonRewordedCommit, 0, len(commits))}
+	messages := make([]string, len(commits))
+	generations := make([]*generation, 0, len(commits))
+	// the first commit whose new message breaks the convention, which is never written
+	var invalid error
+	for i, original := range commits {
+		generated, msg, err := rewordCommit(config, provider, original)
+		if err != nil {
+			return fmt.Errorf("%s: %w", original.Short(), err)
+		}
+		messages[i] = msg
+		if generated == nil {
+			report.Commits = append(report.Commits, jsonRewordedCommit{Hash: original.Hash, Old: original.Message, New: msg})
+			continue
+		}
+		violations := config.File.Convention.Validate(msg)
+		if len(violations) > 0 && invalid == nil {
+			invalid = fmt.Errorf("%s: reworded message does not follow the commit convention: %s", original.Short(), violations[0])
+		}
+		report.Commits = append(report.Commits, jsonRewordedCommit{Hash: original.Hash, Old: original.Message, New: msg, Violations: violations})
+		generations = append(generations, generated)
+		report.Provider = generated.providerInfo
+
+		if !jsonOutput {
+			printFailures(generated)
+			title := fmt.Sprintf("%s (%d of %d)", original.Short(), i+1, len(commits))
+			fmt.Println(ui.NewMessageCard(title, message.Parse(msg), cardWidth))
+			fmt.Println(ui.NewOriginalSubject(strings.SplitN(original.Message, "\n", 2)[0]))
+			if len(violations) > 0 {
+				fmt.Println(ui.NewViolations(violations))
+			}
+		}
+	}
+
+	record := func(outcome ledger.Outcome) {
+		for _, generated := range generations {
+			recordRun(config, generated, outcome, generated.candidates[0].String())
+		}
+	}
+
+	// JSON output never asks, the history is only rewritten with --yes
+	if !config.Options.WithCommit || config.Options.PrintOnly || (jsonOutput && !config.Options.Yes) {
+		record(ledger.OutcomeGenerated)
+		if jsonOutput {
+			return printJSON(report)
+		}
+		return nil
+	}
+	if invalid != nil {
+		record(ledger.OutcomeRejected)
+		return withExitCode(exitValidation, invalid)
+	}
+	if !config.Options.Yes {
+		if !ui.IsInputTerminal() {
+			record(ledger.OutcomeGenerated)
+			fmt.Fprintln(os.Stderr, "stdin is not a terminal, not rewriting; use --yes to reword the commits without confirmation")
+			return nil
+		}
+		if !commit.Confirm(fmt.Sprintf("Rewrite these %d commits?", len(commits))) {
+			record(ledger.OutcomeRejected)
+			return errCancelled
+		}
+	}
+
+	tip, err := history.Rewrite(config.Directory, commits, messages)
+	if err != nil {
+		record(ledger.OutcomeRejected)
+		return err
+	}
+	record(ledger.OutcomeAccepted)
+
+	if jsonOutput {
+		report.Rewritten = true
+		report.Head = tip
+		return printJSON(report)
+	}
+	fmt.Printf("Successfully reworded %d commits, the previous tip was %s.\n", len(commits), commits[len(commits)-1].Hash)
+	return nil
+}
+
+// rewordCommit asks the provider for a new message of one commit. Without --body only the
+// subject is replaced and the body and footers of the original message are kept.
+// Commits without changes keep their message and return no generation.
+func rewordCommit(config ai.Config, provider ai.Provider, original history.Commit) (*generation, string, error) {
+	commitChanges, err := changes.NewCommitChanges(config.Directory, original.Hash)
+	if errors.Is(err, changes.ErrNoChanges) {
+		return nil, original.Message, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	contextBuilder, err := project.NewBuilder(config.Directory)
+	if err != nil {
+		return nil, "", err
+	}
+	contextBuilder.ExcludePaths(config.File.Policy.ExcludePaths)
+	contextBuilder.AddRedaction(config.File.Redaction)
+	contextBuilder.AddScopes(config.File.Scopes)
+	if config.Options.WithBody {
+		contextBuilder.WithBody()
+	}
+	contextBuilder.AddReword(commitChanges, original.Message)
+	projectContext, err := contextBuilder.Build()
+	if err != nil {
+		return nil, "", err
+	}
+
+	generated, responses, err := roundTrip(config, provider, projectContext, 1)
+	if err != nil {
+		return nil, "", err
+	}
+	candidate := enforceScope(config, projectContext, message.Parse(responses[0]))
+	generated.candidates = append(generated.candidates, candidate)
+
+	msg := candidate.String()
+	if _, rest, found := strings.Cut(original.Message, "\n"); found && !config.Options.WithBody && strings.TrimSpace(rest) != "" {
+		msg = candidate.Subject + "\n\n" + strings.TrimSpace(rest)
+	}
+	return generated, msg, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/commit"
+	"github.com/wert2all/ai-commit/ledger"
+	"github.com/wert2all/ai-commit/message"
+	"github.com/wert2all/ai-commit/split"
+	"github.com/wert2all/ai-commit/ui"
+)
+
+const splitUsage = "usage: ai-commit split [--yes] [--without-commit] [--output json]"
+
+type jsonSplitReport struct {
+	Plan      split.Plan      `json:"plan"`
+	Hunks     []changes.Hunk  `json:"hunks"`
+	Provider  ai.ProviderInfo `json:"provider"`
+	Committed bool            `json:"committed"`
+}
+
+// runSplit asks the provider to group the staged hunks into atomic commits and commits them on confirmation
+func runSplit(config ai.Config) error {
+	if len(config.Args) > 1 {
+		return errors.New(splitUsage)
+	}
+	config, err := structuredResponse(config)
+	if err != nil {
+		return err
+	}
+	hunks, err := changes.StagedHunks(config.Directory)
+	if err != nil {
+		return err
+	}
+
+	provider, err := ai.NewProvider(config)
+	if err != nil {
+		return withExitCode(exitProvider, err)
+	}
+	contextBuilder, err := newContextBuilder(config)
+	if err != nil {
+		return err
+	}
+	contextBuilder.AddSplit(hunks)
+	projectContext, err := contextBuilder.Build()
+	if err != nil {
+		return err
+	}
+
+	generated, responses, err := roundTrip(config, provider, projectContext, 1)
+	if err != nil {
+		return err
+	}
+
+	plan, err := split.ParsePlan(responses[0], hunks)
+	if err != nil {
+		return withExitCode(exitProvider, err)
+	}
+	for _, group := range plan.Commits {
+		generated.candidates = append(generated.candidates, message.Parse(group.Message))
+	}
+	summary := planSummary(*plan)
+
+	report := jsonSplitReport{Plan: *plan, Hunks: hunks, Provider: generated.providerInfo}
+	if !jsonOutput {
+		printFailures(generated)
+		fmt.Println(ui.NewProviderInfo(generated.providerInfo))
+		fmt.Println(ui.NewUsageInfo(generated.usage, generated.cost, generated.costKnown, generated.cached))
+		if len(projectContext.Redactions) > 0 {
+			fmt.Println(ui.NewRedactionInfo(projectContext.Redactions))
+		}
+		printPlan(config, *plan, hunks)
+	}
+
+	// JSON output never asks, the plan is only committed with --yes
+	if !config.Options.WithCommit || config.Options.PrintOnly || (jsonOutput && !config.Options.Yes) {
+		recordRun(config, generated, ledger.OutcomeGenerated, summary)
+		if jsonOutput {
+			return printJSON(report)
+		}
+		return nil
+	}
+	if !config.Options.Yes {
+		if !ui.IsInputTerminal() {
+			recordRun(config, generated, ledger.OutcomeGenerated, summary)
+			fmt.Fprintln(os.Stderr, "stdin is not a terminal, not committing; use --yes to commit the plan without confirmation")
+			return nil
+		}
+		if !commit.Confirm(fmt.Sprintf("Create these %d commits?", len(plan.Commits))) {
+			recordRun(config, generated, ledger.OutcomeRejected, summary)
+			return errCancelled
+		}
+	}
+
+	err = split.Apply(config.Directory, *plan, hunks, func(msg string) error {
+		return commit.Commit(msg, config.Directory)
+	})
+	if err != nil {
+		recordRun(config, generated, ledger.OutcomeRejected, summary)
+		return err
+	}
+	recordRun(config, generated, ledger.OutcomeAccepted, summary)
+
+	if jsonOutput {
+		report.Committed = true
+		return printJSON(report)
+	}
+	fmt.Printf("Successfully created %d commits.\n", len(plan.Commits))
+	return nil
+}
+
+// printPlan shows every planned commit as a card followed by the hunks it stages
+func printPlan(config ai.Config, plan split.Plan, hunks []changes.Hunk) {
+	byID := make(map[string]changes.Hunk, len(hunks))
+	for _, hunk := range hunks {
+		byID[hunk.ID] = hunk
+	}
+	for i, group := range plan.Commits {
+		msg := message.Parse(group.Message)
+		fmt.Println(ui.NewMessageCard(fmt.Sprintf("Commit %d of %d", i+1, len(plan.Commits)), msg, cardWidth))
+		if violations := config.File.Convention.Validate(msg.String()); len(violations) > 0 {
+			fmt.Println(ui.NewViolations(violations))
+		}
+		for _, id := range group.Hunks {
+			hunk := byID[id]
+			line := fmt.Sprintf("  %s %s", hunk.ID, hunk.Path)
+			if hunk.Range != "" {
+				line += " " + hunk.Range
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+// planSummary is what the ledger records as the message of a split
+func planSummary(plan split.Plan) string {
+	subjects := make([]string, 0, len(plan.Commits))
+	for _, group := range plan.Commits {
+		subjects = append(subjects, message.Parse(group.Message).Subject)
+	}
+	return strings.Join(subjects, "\n")
+}
@@ -0,0 +1,144 @@
+package split
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/message"
+)
+
+type (
+	// Group is one commit of the plan
+	Group struct {
+		Message string   `json:"message"`
+		Hunks   []string `json:"hunks"`
+	}
+	Plan struct {
+		Commits []Group `json:"commits"`
+	}
+	// CommitFunc commits the staged changes with a message
+	CommitFunc func(msg string) error
+)
+
+// ParsePlan reads the plan returned by the model and checks it against the staged hunks.
+// Hunks the model left out are added to the last commit, so nothing staged is lost.
+func ParsePlan(raw string, hunks []changes.Hunk) (*Plan, error) {
+	start := strings.Index(raw, "{")
+	end := strings.LastIndex(raw, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("provider did not return a split plan")
+	}
+	var plan Plan
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &plan); err != nil {
+		return nil, fmt.Errorf("error parsing split plan: %v", err)
+	}
+
+	ids := make([]string, 0, len(hunks))
+	for _, hunk := range hunks {
+		ids = append(ids, hunk.ID)
+	}
+	assigned := make(map[string]bool)
+	commits := make([]Group, 0, len(plan.Commits))
+	for _, group := range plan.Commits {
+		for _, id := range group.Hunks {
+			switch {
+			case !slices.Contains(ids, id):
+				return nil, fmt.Errorf("split plan refers to unknown hunk %s", id)
+			case assigned[id]:
+				return nil, fmt.Errorf("split plan puts hunk %s in more than one commit", id)
+			}
+			assigned[id] = true
+		}
+		group.Message = message.Parse(group.Message).String()
+		if len(group.Hunks) > 0 && group.Message != "" {
+			commits = append(commits, group)
+		}
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("split plan has no commits")
+	}
+	for _, id := range ids {
+		if !assigned[id] {
+			commits[len(commits)-1].Hunks = append(commits[len(commits)-1].Hunks, id)
+		}
+	}
+	plan.Commits = commits
+	return &plan, nil
+}
+
+// hunks returns the hunks of the group in diff order, which git apply expects
+func (g Group) hunks(hunks []changes.Hunk) []changes.Hunk {
+	result := make([]changes.Hunk, 0, len(g.Hunks))
+	for _, hunk := range hunks {
+		if slices.Contains(g.Hunks, hunk.ID) {
+			result = append(result, hunk)
+		}
+	}
+	return result
+}
+
+// Apply commits the groups of the plan one after another. The index is reset to HEAD and
+// each group is staged with git apply --cached, the working tree is never touched.
+// When any step fails, the commits made so far are undone and the original index is restored.
+func Apply(dir string, plan Plan, hunks []changes.Hunk, commit CommitFunc) error {
+	head, err := git(dir, nil, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return fmt.Errorf("split needs at least one commit: %v", err)
+	}
+	index, err := git(dir, nil, "write-tree")
+	if err != nil {
+		return fmt.Errorf("error saving the index: %v", err)
+	}
+
+	rollback := func(cause error) error {
+		if _, err := git(dir, nil, "reset", "--soft", head); err != nil {
+			return fmt.Errorf("%v; rollback failed, the original HEAD is %s: %v", cause, head, err)
+		}
+		if _, err := git(dir, nil, "read-tree", index); err != nil {
+			return fmt.Errorf("%v; rollback failed, restore the index with git read-tree %s: %v", cause, index, err)
+		}
+		return fmt.Errorf("%v; the commits were rolled back and the staged changes restored", cause)
+	}
+
+	if _, err := git(dir, nil, "reset", "-q", head); err != nil {
+		return rollback(fmt.Errorf("error resetting the index: %v", err))
+	}
+	for i, group := range plan.Commits {
+		if _, err := git(dir, changes.Patch(group.hunks(hunks)), "apply", "--cached", "--whitespace=nowarn", "-"); err != nil {
+			return rollback(fmt.Errorf("error staging commit %d: %v", i+1, err))
+		}
+		if err := commit(group.Message); err != nil {
+			return rollback(fmt.Errorf("error creating commit %d: %v", i+1, err))
+		}
+	}
+
+	// the commits together must amount to exactly what was staged
+	tree, err := git(dir, nil, "rev-parse", "HEAD^{tree}")
+	if err != nil || tree != index {
+		return rollback(fmt.Errorf("the split commits differ from the staged changes"))
+	}
+	return nil
+}
+
+func git(dir string, stdin []byte, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if text := strings.TrimSpace(stderr.String()); text != "" {
+			return "", fmt.Errorf("%s", text)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
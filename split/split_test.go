@@ -0,0 +1,226 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wert2all/ai-commit/changes"
+)
+
+// newRepo creates a repository with list.txt of 30 numbered lines committed,
+// then stages a change at its start and one at its end
+func newRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	run(t, dir, "init", "-q")
+	run(t, dir, "config", "user.name", "Test")
+	run(t, dir, "config", "user.email", "test@example.com")
+	run(t, dir, "config", "commit.gpgsign", "false")
+
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	writeFile(t, dir, "list.txt", strings.Join(lines, "\n")+"\n")
+	run(t, dir, "add", "list.txt")
+	run(t, dir, "commit", "-q", "-m", "initial")
+
+	lines[1] = "line 2 changed"
+	lines[28] = "line 29 changed"
+	writeFile(t, dir, "list.txt", strings.Join(lines, "\n")+"\n")
+	run(t, dir, "add", "list.txt")
+	return dir
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func commitFunc(dir string) CommitFunc {
+	return func(msg string) error {
+		cmd := exec.Command("git", "commit", "-q", "-m", msg)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%v: %s", err, output)
+		}
+		return nil
+	}
+}
+
+func TestApplySplitsHunksOfOneFile(t *testing.T) {
+	dir := newRepo(t)
+	head := run(t, dir, "rev-parse", "HEAD")
+	staged := run(t, dir, "write-tree")
+	// an unstaged change must survive the split
+	writeFile(t, dir, "notes.txt", "not staged\n")
+
+	hunks, err := changes.StagedHunks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2", len(hunks))
+	}
+	plan := Plan{Commits: []Group{
+		{Message: "fix: change the start", Hunks: []string{"H1"}},
+		{Message: "fix: change the end", Hunks: []string{"H2"}},
+	}}
+	if err := Apply(dir, plan, hunks, commitFunc(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	if log := run(t, dir, "log", "--format=%s", head+"..HEAD"); log != "fix: change the end\nfix: change the start" {
+		t.Errorf("unexpected commits:\n%s", log)
+	}
+	first := run(t, dir, "diff", "HEAD~2", "HEAD~1")
+	if !strings.Contains(first, "+line 2 changed") || strings.Contains(first, "line 29 changed") {
+		t.Errorf("first commit has the wrong hunks:\n%s", first)
+	}
+	second := run(t, dir, "diff", "HEAD~1", "HEAD")
+	if !strings.Contains(second, "+line 29 changed") || strings.Contains(second, "line 2 changed") {
+		t.Errorf("second commit has the wrong hunks:\n%s", second)
+	}
+	if tree := run(t, dir, "rev-parse", "HEAD^{tree}"); tree != staged {
+		t.Errorf("split commits end at tree %s, staged was %s", tree, staged)
+	}
+	if status := run(t, dir, "status", "--porcelain"); status != "?? notes.txt" {
+		t.Errorf("working tree changed:\n%s", status)
+	}
+}
+
+func TestApplyRollsBackWhenAPatchDoesNotApply(t *testing.T) {
+	dir := newRepo(t)
+	head := run(t, dir, "rev-parse", "HEAD")
+	staged := run(t, dir, "write-tree")
+
+	hunks, err := changes.StagedHunks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a hunk against content the file does not have
+	hunks = append(hunks, changes.ParseHunks([]byte(`diff --git a/list.txt b/list.txt
+index 1111111..2222222 100644
+--- a/list.txt
++++ b/list.txt
+@@ -15,1 +15,1 @@
+-no such line
++replacement
+`))[0])
+	hunks[2].ID = "H3"
+	plan := Plan{Commits: []Group{
+		{Message: "fix: change the start", Hunks: []string{"H1"}},
+		{Message: "fix: change the rest", Hunks: []string{"H2", "H3"}},
+	}}
+
+	err = Apply(dir, plan, hunks, commitFunc(dir))
+	if err == nil || !strings.Contains(err.Error(), "error staging commit 2") || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRolledBack(t, dir, head, staged)
+}
+
+func TestApplyRollsBackWhenACommitFails(t *testing.T) {
+	dir := newRepo(t)
+	head := run(t, dir, "rev-parse", "HEAD")
+	staged := run(t, dir, "write-tree")
+
+	hunks, err := changes.StagedHunks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := Plan{Commits: []Group{
+		{Message: "fix: change the start", Hunks: []string{"H1"}},
+		{Message: "fix: change the end", Hunks: []string{"H2"}},
+	}}
+	commits := 0
+	commit := func(msg string) error {
+		if commits++; commits == 2 {
+			return errors.New("rejected by a hook")
+		}
+		return commitFunc(dir)(msg)
+	}
+
+	err = Apply(dir, plan, hunks, commit)
+	if err == nil || !strings.Contains(err.Error(), "error creating commit 2: rejected by a hook") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRolledBack(t, dir, head, staged)
+}
+
+func TestApplyRollsBackWhenTheTreeDiffers(t *testing.T) {
+	dir := newRepo(t)
+	head := run(t, dir, "rev-parse", "HEAD")
+	staged := run(t, dir, "write-tree")
+
+	hunks, err := changes.StagedHunks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := Plan{Commits: []Group{{Message: "fix: change both ends", Hunks: []string{"H1", "H2"}}}}
+	// a pre-commit hook staging more than the plan would end at a different tree
+	writeFile(t, dir, "extra.txt", "extra\n")
+	commit := func(msg string) error {
+		run(t, dir, "add", "extra.txt")
+		return commitFunc(dir)(msg)
+	}
+
+	err = Apply(dir, plan, hunks, commit)
+	if err == nil || !strings.Contains(err.Error(), "differ from the staged changes") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRolledBack(t, dir, head, staged)
+}
+
+// assertRolledBack checks that HEAD and the index are as before the split
+func assertRolledBack(t *testing.T, dir, head, staged string) {
+	t.Helper()
+	if current := run(t, dir, "rev-parse", "HEAD"); current != head {
+		t.Errorf("HEAD is %s after the rollback, want %s", current, head)
+	}
+	if index := run(t, dir, "write-tree"); index != staged {
+		t.Errorf("index is %s after the rollback, want %s", index, staged)
+	}
+}
+
+func TestParsePlan(t *testing.T) {
+	hunks := []changes.Hunk{{ID: "H1"}, {ID: "H2"}, {ID: "H3"}}
+
+	plan, err := ParsePlan("Here is the plan:\n```json\n"+`{"commits": [{"message": "feat: add a", "hunks": ["H1"]}, {"message": "fix: b", "hunks": ["H3"]}]}`+"\n```", hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// hunks left out go to the last commit
+	if got := plan.Commits[1].Hunks; len(got) != 2 || got[0] != "H3" || got[1] != "H2" {
+		t.Errorf("last commit has hunks %v, want [H3 H2]", got)
+	}
+
+	for raw, want := range map[string]string{
+		`{"commits": [{"message": "feat: a", "hunks": ["H9"]}]}`:                                               "unknown hunk H9",
+		`{"commits": [{"message": "feat: a", "hunks": ["H1"]}, {"message": "fix: b", "hunks": ["H1", "H2"]}]}`: "more than one commit",
+		`{"commits": []}`: "no commits",
+		`no plan at all`:  "did not return a split plan",
+	} {
+		if _, err := ParsePlan(raw, hunks); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParsePlan(%s) = %v, want an error containing %q", raw, err, want)
+		}
+	}
+}
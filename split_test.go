@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wert2all/ai-commit/ai"
+)
+
+// heuristicConfig selects the heuristic provider for a subcommand, in a directory
+// that is not a repository so nothing but the provider check can pass
+func heuristicConfig(t *testing.T, args ...string) ai.Config {
+	t.Helper()
+	return ai.Config{Type: ai.ProviderHeuristic, Directory: t.TempDir(), Args: args, Options: ai.Options{To: "HEAD", Format: "markdown"}}
+}
+
+func assertHeuristicRefused(t *testing.T, err error) {
+	t.Helper()
+	if err == nil || !strings.Contains(err.Error(), "heuristic provider only generates commit messages") {
+		t.Errorf("error = %v, want the heuristic provider to be refused", err)
+	}
+	if exitCode(err) != exitConfig {
+		t.Errorf("exit code = %d, want %d", exitCode(err), exitConfig)
+	}
+}
+
+func TestSplitRefusesHeuristicProvider(t *testing.T) {
+	assertHeuristicRefused(t, runSplit(heuristicConfig(t, "split")))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/ledger"
+)
+
+const dateLayout = "2006-01-02"
+
+// recordRun appends a generation and what happened to it to the usage ledger.
+// The ledger is best effort and never fails a run.
+func recordRun(config ai.Config, generated *generation, outcome ledger.Outcome, commitMsg string) {
+	entry := ledger.Entry{
+		Time:             time.Now(),
+		Repo:             config.RepoRoot,
+		Provider:         string(generated.providerInfo.Type),
+		Model:            generated.providerInfo.Model,
+		PromptTokens:     generated.usage.PromptTokens,
+		CompletionTokens: generated.usage.CompletionTokens,
+		LatencyMS:        generated.latency.Milliseconds(),
+		Outcome:          outcome,
+		Message:          commitMsg,
+	}
+	if generated.costKnown {
+		entry.CostUSD = &generated.cost
+	}
+	if err := ledger.Append(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "ai-commit: %v\n", err)
+	}
+}
+
+// commitOutcome tells apart messages committed as generated from edited ones
+func commitOutcome(generated *generation, commitMsg string) ledger.Outcome {
+	for _, candidate := range generated.candidates {
+		if candidate.String() == commitMsg {
+			return ledger.OutcomeAccepted
+		}
+	}
+	return ledger.OutcomeEdited
+}
+
+func runStats(config ai.Config) error {
+	filter := ledger.Filter{}
+	if config.Options.Since != "" {
+		since, err := time.ParseInLocation(dateLayout, config.Options.Since, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --since date: %v", err)
+		}
+		filter.Since = since
+	}
+	if config.Options.Until != "" {
+		until, err := time.ParseInLocation(dateLayout, config.Options.Until, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --until date: %v", err)
+		}
+		// include the whole day
+		filter.Until = until.AddDate(0, 0, 1)
+	}
+
+	entries, err := ledger.Read(filter)
+	if err != nil {
+		return err
+	}
+	groups, err := ledger.Aggregate(entries, config.Options.GroupBy)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return printJSON(groups)
+	}
+
+	if len(groups) == 0 {
+		fmt.Println("No runs recorded yet.")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "\tRuns\tAccepted\tEdited\tRejected\tAcceptance\tTokens\tCost\tAvg latency\t")
+	for _, group := range append(groups, sumGroups(groups)) {
+		cost := fmt.Sprintf("$%.4f", group.CostUSD)
+		if group.UnknownCost > 0 {
+			cost += "+?"
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%d\t%.0f%%\t%d\t%s\t%s\t\n",
+			group.Key,
+			group.Runs,
+			group.Accepted,
+			group.Edited,
+			group.Rejected,
+			group.AcceptanceRate()*100,
+			group.PromptTokens+group.CompletionTokens,
+			cost,
+			group.AverageLatency().Round(time.Millisecond),
+		)
+	}
+	return writer.Flush()
+}
+
+func sumGroups(groups []ledger.Group) ledger.Group {
+	total := ledger.Group{Key: "total"}
+	for _, group := range groups {
+		total.Runs += group.Runs
+		total.Accepted += group.Accepted
+		total.Edited += group.Edited
+		total.Rejected += group.Rejected
+		total.Generated += group.Generated
+		total.PromptTokens += group.PromptTokens
+		total.CompletionTokens += group.CompletionTokens
+		total.CostUSD += group.CostUSD
+		total.UnknownCost += group.UnknownCost
+		total.TotalLatencyMS += group.TotalLatencyMS
+	}
+	return total
+}
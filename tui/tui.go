@@ -0,0 +1,379 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/changes"
+	"github.com/wert2all/ai-commit/message"
+	"github.com/wert2all/ai-commit/project"
+	"github.com/wert2all/ai-commit/ui"
+)
+
+const maxListedFiles = 10
+
+type (
+	// Settings are the generation options the user can change from the UI
+	Settings struct {
+		Provider         ai.ProviderType
+		Model            string
+		WithFilesContent bool
+		Hint             string
+		// Providers are offered when cycling with "p", all of ai.ProviderTypes when empty
+		Providers []ai.ProviderType
+	}
+	Generation struct {
+		ProviderInfo ai.ProviderInfo
+		Files        []changes.FileDiff
+		Messages     []message.Message
+		Usage        ai.Usage
+		Cost         float64
+		CostKnown    bool
+		Cached       bool
+		Redactions   []project.Redaction
+	}
+	GenerateFunc func(settings Settings) (*Generation, error)
+
+	Result struct {
+		Message  message.Message
+		Accepted bool
+		// OpenEditor asks to finish editing the message in $EDITOR before committing
+		OpenEditor bool
+		Files      []changes.FileDiff
+	}
+
+	mode int
+
+	generatedMsg struct {
+		generation *Generation
+		err        error
+	}
+
+	model struct {
+		generate   GenerateFunc
+		settings   Settings
+		generation *Generation
+		selected   int
+		err        error
+		generating bool
+		mode       mode
+		width      int
+		result     Result
+
+		spinner spinner.Model
+		editor  textarea.Model
+		input   textinput.Model
+	}
+)
+
+const (
+	modeView mode = iota
+	modeEdit
+	modeHint
+	modeModel
+)
+
+var (
+	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#6c6c6c", Dark: "#9a9a9a"})
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#22863a"))
+	deletedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#cb2431"))
+)
+
+// Run shows the interactive review screen and returns the message chosen by the user
+func Run(settings Settings, generate GenerateFunc, width int) (Result, error) {
+	final, err := tea.NewProgram(newModel(settings, generate, width)).Run()
+	if err != nil {
+		return Result{}, fmt.Errorf("error running interactive UI: %v", err)
+	}
+	return final.(model).result, nil
+}
+
+func newModel(settings Settings, generate GenerateFunc, width int) model {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+
+	editor := textarea.New()
+	editor.ShowLineNumbers = false
+	editor.CharLimit = 0
+	editor.SetWidth(width)
+	editor.SetHeight(10)
+
+	input := textinput.New()
+	input.Width = width - 4
+
+	return model{
+		generate:   generate,
+		settings:   settings,
+		generating: true,
+		width:      width,
+		spinner:    s,
+		editor:     editor,
+		input:      input,
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.regenerate())
+}
+
+func (m model) regenerate() tea.Cmd {
+	generate, settings := m.generate, m.settings
+	return func() tea.Msg {
+		generation, err := generate(settings)
+		return generatedMsg{generation: generation, err: err}
+	}
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case generatedMsg:
+		m.generating = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.generation = msg.generation
+			m.selected = 0
+		}
+		return m, nil
+
+	case spinner.TickMsg:
+		if !m.generating {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case modeEdit:
+			return m.updateEdit(msg)
+		case modeHint, modeModel:
+			return m.updateInput(msg)
+		default:
+			return m.updateView(msg)
+		}
+	}
+	return m, nil
+}
+
+func (m model) updateView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc", "n":
+		return m, tea.Quit
+	}
+	if m.generating {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "enter", "y":
+		if current, ok := m.current(); ok {
+			m.result = Result{Message: current, Accepted: true}
+			return m, tea.Quit
+		}
+	case "up", "k", "shift+tab":
+		if m.selected > 0 {
+			m.selected--
+		}
+	case "down", "j", "tab":
+		if m.generation != nil && m.selected < len(m.generation.Messages)-1 {
+			m.selected++
+		}
+	case "E":
+		if current, ok := m.current(); ok {
+			m.result = Result{Message: current, Accepted: true, OpenEditor: true, Files: m.generation.Files}
+			return m, tea.Quit
+		}
+	case "e":
+		if current, ok := m.current(); ok {
+			m.mode = modeEdit
+			m.editor.SetValue(current.String())
+			return m, m.editor.Focus()
+		}
+	case "r":
+		return m.startGeneration()
+	case "h":
+		m.mode = modeHint
+		m.input.Placeholder = "e.g. mention the migration"
+		m.input.SetValue(m.settings.Hint)
+		return m, m.input.Focus()
+	case "m":
+		m.mode = modeModel
+		m.input.Placeholder = "model name, empty for provider default"
+		m.input.SetValue(m.settings.Model)
+		return m, m.input.Focus()
+	case "p":
+		providers := m.settings.Providers
+		if len(providers) == 0 {
+			providers = ai.ProviderTypes
+		}
+		index := slices.Index(providers, m.settings.Provider)
+		m.settings.Provider = providers[(index+1)%len(providers)]
+		m.settings.Model = ""
+		return m.startGeneration()
+	case "f":
+		m.settings.WithFilesContent = !m.settings.WithFilesContent
+		return m.startGeneration()
+	}
+	return m, nil
+}
+
+func (m model) updateEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeView
+		m.editor.Blur()
+		return m, nil
+	case "ctrl+s":
+		m.mode = modeView
+		m.editor.Blur()
+		if edited := message.Parse(m.editor.Value()); edited.Subject != "" {
+			m.generation.Messages[m.selected] = edited
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.editor, cmd = m.editor.Update(msg)
+	return m, cmd
+}
+
+func (m model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeView
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		if m.mode == modeHint {
+			m.settings.Hint = m.input.Value()
+		} else {
+			m.settings.Model = strings.TrimSpace(m.input.Value())
+		}
+		m.mode = modeView
+		m.input.Blur()
+		return m.startGeneration()
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m model) startGeneration() (tea.Model, tea.Cmd) {
+	m.generating = true
+	m.err = nil
+	return m, tea.Batch(m.spinner.Tick, m.regenerate())
+}
+
+func (m model) current() (message.Message, bool) {
+	if m.generation == nil || len(m.generation.Messages) == 0 {
+		return message.Message{}, false
+	}
+	return m.generation.Messages[m.selected], true
+}
+
+func (m model) View() string {
+	var view strings.Builder
+
+	view.WriteString(m.settingsView() + "\n\n")
+
+	if m.generation != nil {
+		view.WriteString(m.filesView() + "\n")
+	}
+
+	switch {
+	case m.generating:
+		view.WriteString(m.spinner.View() + " Generating commit message...\n")
+	case m.err != nil:
+		view.WriteString(ui.NewError(m.err.Error(), m.width).String() + "\n")
+	}
+
+	switch m.mode {
+	case modeEdit:
+		view.WriteString(m.editor.View() + "\n")
+		view.WriteString(helpStyle.Render("ctrl+s save • esc discard") + "\n")
+		return view.String()
+	case modeHint:
+		view.WriteString("Hint: " + m.input.View() + "\n")
+		view.WriteString(helpStyle.Render("enter regenerate • esc cancel") + "\n")
+		return view.String()
+	case modeModel:
+		view.WriteString("Model: " + m.input.View() + "\n")
+		view.WriteString(helpStyle.Render("enter regenerate • esc cancel") + "\n")
+		return view.String()
+	}
+
+	if current, ok := m.current(); ok && !m.generating {
+		title := "Commit message"
+		if count := len(m.generation.Messages); count > 1 {
+			title = fmt.Sprintf("Commit message %d/%d", m.selected+1, count)
+		}
+		view.WriteString(ui.NewMessageCard(title, current, m.width).String() + "\n")
+	}
+
+	view.WriteString(helpStyle.Render(
+		"enter accept • e edit • E $EDITOR • r regenerate • h hint • p provider • m model • f files content • ↑/↓ choose • q quit",
+	) + "\n")
+	return view.String()
+}
+
+func (m model) settingsView() string {
+	provider := string(m.settings.Provider)
+	if m.generation != nil && !m.generating {
+		provider = m.generation.ProviderInfo.Name + " with " + m.generation.ProviderInfo.Model
+	} else if m.settings.Model != "" {
+		provider += " with " + m.settings.Model
+	}
+
+	filesContent := "off"
+	if m.settings.WithFilesContent {
+		filesContent = "on"
+	}
+
+	line := fmt.Sprintf("Using %s • files content: %s", provider, filesContent)
+	if m.settings.Hint != "" {
+		line += " • hint: " + m.settings.Hint
+	}
+	if m.generation != nil && !m.generating {
+		line += "\n" + helpStyle.Render(ui.NewUsageInfo(m.generation.Usage, m.generation.Cost, m.generation.CostKnown, m.generation.Cached))
+		if len(m.generation.Redactions) > 0 {
+			line += "\n" + helpStyle.Render(ui.NewRedactionInfo(m.generation.Redactions))
+		}
+	}
+	return line
+}
+
+func (m model) filesView() string {
+	var view strings.Builder
+	added, deleted := 0, 0
+	for i, file := range m.generation.Files {
+		added += file.Added
+		deleted += file.Deleted
+		if i < maxListedFiles {
+			view.WriteString(fmt.Sprintf("  %s %s %s\n",
+				addedStyle.Render(fmt.Sprintf("+%d", file.Added)),
+				deletedStyle.Render(fmt.Sprintf("-%d", file.Deleted)),
+				file.Path,
+			))
+		}
+	}
+	if hidden := len(m.generation.Files) - maxListedFiles; hidden > 0 {
+		view.WriteString(fmt.Sprintf("  ... and %d more\n", hidden))
+	}
+
+	summary := fmt.Sprintf("%d files changed, %s, %s\n",
+		len(m.generation.Files),
+		addedStyle.Render(fmt.Sprintf("%d insertions(+)", added)),
+		deletedStyle.Render(fmt.Sprintf("%d deletions(-)", deleted)),
+	)
+	return summary + view.String()
+}
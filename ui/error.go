@@ -16,16 +16,16 @@ func NewError(message string, width int) UIError {
 
 // String renders the card as a string
 func (e UIError) String() string {
-	cardStyle = lipgloss.NewStyle().
+	errorCardStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(errorColor).
 		Width(e.width)
 
-	titleStyle = lipgloss.NewStyle().
+	errorTitleStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#FFFDF5")).
 		Background(errorColor).
 		Width(e.width)
 
-	inner := lipgloss.JoinVertical(lipgloss.Left, titleStyle.Render(e.message))
-	return cardStyle.Render(inner)
+	inner := lipgloss.JoinVertical(lipgloss.Left, errorTitleStyle.Render(e.message))
+	return errorCardStyle.Render(inner)
 }
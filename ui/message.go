@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/wert2all/ai-commit/message"
+)
+
+var (
+	muted = lipgloss.AdaptiveColor{Light: "#6c6c6c", Dark: "#9a9a9a"}
+
+	subjectStyle  = lipgloss.NewStyle().Bold(true)
+	footerStyle   = lipgloss.NewStyle().Foreground(muted)
+	breakingStyle = lipgloss.NewStyle().Bold(true).Foreground(errorColor)
+)
+
+// NewMessageCard renders subject, body and footers of a commit message distinctly
+func NewMessageCard(title string, msg message.Message, width int) Card {
+	parts := []string{subjectStyle.Render(msg.Subject)}
+	if msg.Body != "" {
+		parts = append(parts, msg.Body)
+	}
+	if len(msg.Footers) > 0 {
+		footers := make([]string, 0, len(msg.Footers))
+		for _, footer := range msg.Footers {
+			if footer.IsBreaking() {
+				footers = append(footers, breakingStyle.Render(footer.String()))
+			} else {
+				footers = append(footers, footerStyle.Render(footer.String()))
+			}
+		}
+		parts = append(parts, strings.Join(footers, "\n"))
+	}
+
+	return NewCard(title, strings.Join(parts, "\n\n"), width)
+}
+
+// NewViolations lists convention violations below a message card
+func NewViolations(violations []message.Violation) string {
+	lines := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		lines = append(lines, breakingStyle.Render("! ")+footerStyle.Render(violation.String()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NewOriginalSubject shows the subject a reworded commit had, below its new message card
+func NewOriginalSubject(subject string) string {
+	return footerStyle.Render("  was: " + subject)
+}
@@ -0,0 +1,17 @@
+package ui
+
+import (
+	"os"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// IsTerminal reports whether both stdin and stdout are attached to a terminal
+func IsTerminal() bool {
+	return term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+}
+
+// IsInputTerminal reports whether stdin is attached to a terminal, so the user can be asked
+func IsInputTerminal() bool {
+	return term.IsTerminal(os.Stdin.Fd())
+}
@@ -1,10 +1,12 @@
 package ui
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/wert2all/ai-commit/ai"
+	"github.com/wert2all/ai-commit/project"
 )
 
 var (
@@ -33,3 +35,25 @@ func NewProviderInfo(providerInfo ai.ProviderInfo) string {
 	fullResponse.WriteString(providerInfo.Model)
 	return fullResponse.String()
 }
+
+// NewUsageInfo describes token usage and estimated cost below the provider line
+func NewUsageInfo(usage ai.Usage, cost float64, costKnown bool, cached bool) string {
+	if cached {
+		return "Cached response, no tokens used"
+	}
+
+	var fullResponse strings.Builder
+
+	fullResponse.WriteString(fmt.Sprintf("Tokens: %d prompt + %d completion", usage.PromptTokens, usage.CompletionTokens))
+	if costKnown {
+		fullResponse.WriteString(fmt.Sprintf(", estimated cost $%.4f", cost))
+	} else {
+		fullResponse.WriteString(", cost unknown")
+	}
+	return fullResponse.String()
+}
+
+// NewRedactionInfo lists the secrets replaced before the context was sent
+func NewRedactionInfo(redactions []project.Redaction) string {
+	return "Redacted before sending: " + project.DescribeRedactions(redactions)
+}